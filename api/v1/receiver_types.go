@@ -0,0 +1,574 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:validation:Enum=Async;AwaitAllPreferSuccessful;AwaitAllPreferFailed;AwaitAllReport;FirstSuccessful;Quorum;Primary
+type ResponseType string
+
+const (
+	Async                    ResponseType = "Async"
+	AwaitAllPreferSuccessful ResponseType = "AwaitAllPreferSuccessful"
+	AwaitAllPreferFailed     ResponseType = "AwaitAllPreferFailed"
+	AwaitAllReport           ResponseType = "AwaitAllReport"
+	FirstSuccessful          ResponseType = "FirstSuccessful"
+	Quorum                   ResponseType = "Quorum"
+	Primary                  ResponseType = "Primary"
+)
+
+// ReceiverSpec defines the desired state of Receiver
+// +kubebuilder:validation:XValidation:rule="!has(self.response) || !has(self.response.primaryTarget) || self.targets.exists(t, has(t.name) && t.name == self.response.primaryTarget)",message="response.primaryTarget must reference the name of a target"
+// +kubebuilder:validation:XValidation:rule="!has(self.response) || !has(self.response.primaryTarget) || !self.targets.exists(t, has(t.name) && t.name == self.response.primaryTarget && ((has(t.fireAndForget) && t.fireAndForget) || has(t.delay) || has(t.debounce) || has(t.sampling)))",message="response.primaryTarget can not reference a fireAndForget, delayed, debounced or sampled target"
+// +kubebuilder:validation:XValidation:rule="!has(self.streaming) || self.targets.all(t, !has(t.debounce) || !has(t.debounce.key) || !has(t.debounce.key.bodyField))",message="debounce keys from body fields are not supported for streamed bodies"
+// +kubebuilder:validation:XValidation:rule="!has(self.streaming) || self.targets.all(t, !has(t.sampling) || !has(t.sampling.key) || !has(t.sampling.key.bodyField))",message="sampling keys from body fields are not supported for streamed bodies"
+// +kubebuilder:validation:XValidation:rule="!has(self.streaming) || !has(self.orderingKey) || !has(self.orderingKey.bodyField)",message="ordering keys from body fields are not supported for streamed bodies"
+type ReceiverSpec struct {
+	// Suspend reconciliation
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Response defines how the webhook request is answered
+	// +kubebuilder:default={}
+	Response Response `json:"response,omitempty"`
+
+	// Body size limit
+	// +kubebuilder:validation:Minimum=0
+	BodySizeLimit int64 `json:"bodySizeLimit,omitempty"`
+
+	// BodySizeLimitAction defines whether requests exceeding the body size limit are rejected
+	// with 413 Payload Too Large or truncated to the limit.
+	// +kubebuilder:default=Truncate
+	BodySizeLimitAction BodySizeLimitAction `json:"bodySizeLimitAction,omitempty"`
+
+	// Streaming tees the request body to all targets while it is received instead of buffering it in memory.
+	// Retries are not supported for streamed bodies.
+	// +optional
+	Streaming *StreamingOptions `json:"streaming,omitempty"`
+
+	// OrderingKey serializes the deliveries of requests with the same key to a target, including their retries.
+	// Requests with different keys are still delivered concurrently, requests without a key share one sequence.
+	// +optional
+	OrderingKey *RequestKey `json:"orderingKey,omitempty"`
+
+	// Timeout for the target requests
+	// +kubebuilder:default="10s"
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+
+	// Targets to forward (clone) requests to
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=64
+	// +kubebuilder:validation:XValidation:rule="self.all(t, !has(t.name) || self.exists_one(o, has(o.name) && o.name == t.name))",message="target names must be unique"
+	Targets []Target `json:"targets"`
+}
+
+// +kubebuilder:validation:XValidation:rule="!has(self.type) || self.type != 'Primary' || has(self.primaryTarget)",message="primaryTarget is required for type Primary"
+type Response struct {
+	// Type decides when and with which target response the webhook request is answered
+	// +kubebuilder:default=Async
+	Type ResponseType `json:"type,omitempty"`
+
+	// Quorum is the number of successful target responses required before responding.
+	// Only used with type Quorum, defaults to a majority of the resolved targets.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Quorum int32 `json:"quorum,omitempty"`
+
+	// PrimaryTarget is the name of the target whose response is returned.
+	// Only used with type Primary, all other targets are mirrors.
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	PrimaryTarget string `json:"primaryTarget,omitempty"`
+
+	// Report configures the response of type AwaitAllReport
+	// +optional
+	Report *ReportOptions `json:"report,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="has(self.service.name) != has(self.serviceSelector)",message="exactly one of service.name or serviceSelector is required"
+// +kubebuilder:validation:XValidation:rule="!(has(self.namespace) && has(self.namespaceSelector))",message="namespace and namespaceSelector are mutually exclusive"
+type Target struct {
+	// Name of the target, used to reference it from other fields
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// HTTP Path
+	// +kubebuilder:default="/"
+	Path string `json:"path,omitempty"`
+
+	// Service name and port
+	Service ServiceReference `json:"service"`
+
+	// ServiceSelector selects the target services by labels instead of service.name.
+	// The port is selected by service.port in each matching service.
+	// +optional
+	ServiceSelector *metav1.LabelSelector `json:"serviceSelector,omitempty"`
+
+	// Namespace of the service, defaults to the namespace of the Receiver.
+	// Mutually exclusive with namespaceSelector, either of them is required for ClusterReceivers.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// NamespaceSelector defines a selector to select namespaces where services are looked up
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// SuccessCodes are the response status codes considered successful, defaults to 200-299
+	// +optional
+	SuccessCodes []StatusCodeRange `json:"successCodes,omitempty"`
+
+	// FailureCodes are the response status codes considered failed, defaults to 400-599.
+	// SuccessCodes take precedence over FailureCodes.
+	// +optional
+	FailureCodes []StatusCodeRange `json:"failureCodes,omitempty"`
+
+	// SuccessExpression is a CEL expression which must evaluate to true for a response with a success code to be successful.
+	// The variables statusCode, headers and body are available, body is the decoded json response or the raw body as string.
+	// +optional
+	SuccessExpression string `json:"successExpression,omitempty"`
+
+	// Retry failed requests to this target
+	// +optional
+	Retry *RetryPolicy `json:"retry,omitempty"`
+
+	// Timeout for the requests to this target, overrides the timeout of the receiver
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// BodySizeLimit truncates the request body forwarded to this target.
+	// The body size limit of the receiver still applies to the request as a whole.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	BodySizeLimit int64 `json:"bodySizeLimit,omitempty"`
+
+	// FireAndForget forwards requests to this target without awaiting its response.
+	// The target is ignored by the response type of the receiver and can not be the primary target.
+	// +optional
+	FireAndForget bool `json:"fireAndForget,omitempty"`
+
+	// CircuitBreaker stops deliveries to this target while it keeps failing
+	// +optional
+	CircuitBreaker *CircuitBreakerPolicy `json:"circuitBreaker,omitempty"`
+
+	// Delay postpones deliveries to this target, its response is never awaited
+	// +optional
+	Delay *metav1.Duration `json:"delay,omitempty"`
+
+	// Debounce coalesces requests to this target, its response is never awaited
+	// +optional
+	Debounce *DebouncePolicy `json:"debounce,omitempty"`
+
+	// Sampling forwards only a subset of the requests to this target, sampled out requests are not awaited
+	// +optional
+	Sampling *SamplingPolicy `json:"sampling,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Reject;Truncate
+type BodySizeLimitAction string
+
+const (
+	Reject   BodySizeLimitAction = "Reject"
+	Truncate BodySizeLimitAction = "Truncate"
+)
+
+type StreamingOptions struct {
+	// BufferSize is the number of bytes buffered in memory per target.
+	// If a target consumes the body slower than it is received the remaining data is spilled to disk.
+	// +kubebuilder:default=1048576
+	// +kubebuilder:validation:Minimum=0
+	BufferSize int64 `json:"bufferSize,omitempty"`
+}
+
+// ReportStatusCode decides the status code of an AwaitAllReport response
+// +kubebuilder:validation:Enum=OK;MultiStatus;BadGatewayOnFailure
+type ReportStatusCode string
+
+const (
+	// ReportOK always responds with 200 OK
+	ReportOK ReportStatusCode = "OK"
+	// ReportMultiStatus always responds with 207 Multi-Status
+	ReportMultiStatus ReportStatusCode = "MultiStatus"
+	// ReportBadGatewayOnFailure responds with 502 Bad Gateway if any target failed and 200 OK otherwise
+	ReportBadGatewayOnFailure ReportStatusCode = "BadGatewayOnFailure"
+)
+
+type ReportOptions struct {
+	// StatusCode of the report response
+	// +kubebuilder:default=OK
+	StatusCode ReportStatusCode `json:"statusCode,omitempty"`
+
+	// BodySizeLimit limits the size of each target response body included in the report
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	BodySizeLimit int64 `json:"bodySizeLimit,omitempty"`
+}
+
+// StatusCodeRange is either a single status code like 409 or an inclusive range like 200-299
+// +kubebuilder:validation:Pattern=`^[1-5][0-9]{2}(-[1-5][0-9]{2})?$`
+type StatusCodeRange string
+
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts including the first request
+	// +kubebuilder:default=3
+	// +kubebuilder:validation:Minimum=1
+	Attempts int32 `json:"attempts,omitempty"`
+
+	// Interval between attempts
+	// +kubebuilder:default="1s"
+	Interval metav1.Duration `json:"interval,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="has(self.consecutiveFailures) || has(self.failurePercentage)",message="at least one of consecutiveFailures or failurePercentage is required"
+type CircuitBreakerPolicy struct {
+	// ConsecutiveFailures opens the circuit after this number of failed deliveries in a row
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+
+	// FailurePercentage opens the circuit once this percentage of the deliveries within the window failed
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	FailurePercentage int32 `json:"failurePercentage,omitempty"`
+
+	// MinimumDeliveries is the number of deliveries within the window required before failurePercentage is evaluated
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=1
+	MinimumDeliveries int32 `json:"minimumDeliveries,omitempty"`
+
+	// Window failurePercentage is calculated over
+	// +kubebuilder:default="1m"
+	Window metav1.Duration `json:"window,omitempty"`
+
+	// OpenDuration is the time the circuit stays open before probe deliveries are sent
+	// +kubebuilder:default="30s"
+	OpenDuration metav1.Duration `json:"openDuration,omitempty"`
+
+	// HalfOpenProbes is the number of successful probe deliveries required to close the circuit again.
+	// The circuit opens again if any of them fails.
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	HalfOpenProbes int32 `json:"halfOpenProbes,omitempty"`
+
+	// OpenAction defines what happens with deliveries while the circuit is open.
+	// Skip drops them, Queue delivers them once the circuit allows it again and DeadLetter keeps them in the admin api.
+	// +kubebuilder:default=Skip
+	OpenAction CircuitOpenAction `json:"openAction,omitempty"`
+
+	// QueueTimeout is the maximum time a queued delivery waits for the circuit to close
+	// +kubebuilder:default="5m"
+	QueueTimeout metav1.Duration `json:"queueTimeout,omitempty"`
+
+	// QueueLimit is the maximum number of deliveries queued per target, further deliveries are dead-lettered
+	// +kubebuilder:default=100
+	// +kubebuilder:validation:Minimum=1
+	QueueLimit int32 `json:"queueLimit,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Skip;Queue;DeadLetter
+type CircuitOpenAction string
+
+const (
+	CircuitSkip       CircuitOpenAction = "Skip"
+	CircuitQueue      CircuitOpenAction = "Queue"
+	CircuitDeadLetter CircuitOpenAction = "DeadLetter"
+)
+
+type DebouncePolicy struct {
+	// Window in which requests with the same key are coalesced into one delivery, it starts with the first request
+	Window metav1.Duration `json:"window"`
+
+	// Key identifies the requests which are coalesced, requests without a key share one window
+	// +optional
+	Key *RequestKey `json:"key,omitempty"`
+
+	// Keep decides which request of a window is delivered.
+	// First delivers the first request right away, Last delivers the last request once the window ends.
+	// +kubebuilder:default=Last
+	Keep DebounceKeep `json:"keep,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=First;Last
+type DebounceKeep string
+
+const (
+	DebounceFirst DebounceKeep = "First"
+	DebounceLast  DebounceKeep = "Last"
+)
+
+type SamplingPolicy struct {
+	// Percentage of the requests forwarded to the target
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Percentage int32 `json:"percentage"`
+
+	// Key samples requests deterministically by a hash of the key instead of randomly.
+	// Requests with the same key are either always or never forwarded.
+	// +optional
+	Key *RequestKey `json:"key,omitempty"`
+}
+
+// RequestKey extracts a key from a request header or a field of the json request body
+// +kubebuilder:validation:XValidation:rule="has(self.header) != has(self.bodyField)",message="exactly one of header or bodyField is required"
+type RequestKey struct {
+	// Header is the name of a request header
+	// +optional
+	Header string `json:"header,omitempty"`
+
+	// BodyField is the dot separated path of a field in the json request body, e.g. repository.full_name.
+	// Fields of streamed bodies are not supported.
+	// +optional
+	BodyField string `json:"bodyField,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="has(self.port)",message="port is required"
+type ServiceReference struct {
+	// Name of the service, mutually exclusive with serviceSelector
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Port of the service
+	Port ServicePort `json:"port,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="has(self.name) != has(self.number)",message="exactly one of name or number is required"
+type ServicePort struct {
+	// Name of the port, mutually exclusive with Number
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Number of the port, mutually exclusive with Name
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Number int32 `json:"number,omitempty"`
+}
+
+// ReceiverStatus defines the observed state of Receiver
+type ReceiverStatus struct {
+	// Conditions holds the conditions of the Receiver
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The generated webhook path
+	WebhookPath string `json:"webhookPath,omitempty"`
+
+	// Services holds references to the resolved target services
+	// +optional
+	Services []ResourceReference `json:"services,omitempty"`
+
+	// Targets holds the resolution state of each target per selected namespace
+	// +optional
+	Targets []TargetStatus `json:"targets,omitempty"`
+
+	// Deliveries holds statistics about the webhook deliveries to the targets
+	// +optional
+	Deliveries *DeliveryStatus `json:"deliveries,omitempty"`
+}
+
+// TargetStatus is the resolution state of a target service
+type TargetStatus struct {
+	// Name of the target
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Service is the name of the target service, empty if no service matches the service selector
+	// +optional
+	Service string `json:"service,omitempty"`
+
+	// Namespace of the service, empty if no namespace was selected
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Port is the resolved service port
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// Resolved is true if webhooks are forwarded to the service
+	Resolved bool `json:"resolved"`
+
+	// Reason is a brief CamelCase reason of the resolution state
+	Reason string `json:"reason"`
+
+	// Message describes why the target could not be resolved
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// DeliveryStatus holds the delivery statistics of a receiver.
+// Counts are calculated over a rolling window and summed over all replicas.
+type DeliveryStatus struct {
+	// Window is the duration of the rolling window the counts are calculated over
+	Window metav1.Duration `json:"window"`
+
+	// LastRequestTime is the time of the last request delivered to any target
+	// +optional
+	LastRequestTime *metav1.Time `json:"lastRequestTime,omitempty"`
+
+	// Successful is the number of successful deliveries within the window
+	Successful int64 `json:"successful"`
+
+	// Failed is the number of failed deliveries within the window
+	Failed int64 `json:"failed"`
+
+	// Targets holds the delivery statistics per target
+	// +optional
+	Targets []TargetDeliveryStatus `json:"targets,omitempty"`
+}
+
+// TargetDeliveryStatus holds the delivery statistics of a single target service
+type TargetDeliveryStatus struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Port      int32  `json:"port"`
+
+	// +optional
+	LastRequestTime *metav1.Time `json:"lastRequestTime,omitempty"`
+
+	// +optional
+	LastSuccessTime *metav1.Time `json:"lastSuccessTime,omitempty"`
+
+	// +optional
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+
+	// LastStatusCode is the status code of the last response, 504 if the target could not be reached
+	// +optional
+	LastStatusCode int32 `json:"lastStatusCode,omitempty"`
+
+	// Successful is the number of successful deliveries within the window
+	Successful int64 `json:"successful"`
+
+	// Failed is the number of failed deliveries within the window
+	Failed int64 `json:"failed"`
+
+	// CircuitState is the most open state of the circuit breakers of the target across all replicas, one of Closed, Open or HalfOpen
+	// +optional
+	CircuitState string `json:"circuitState,omitempty"`
+}
+
+// ResourceReference metadata to lookup another resource
+type ResourceReference struct {
+	Kind       string `json:"kind,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+}
+
+const (
+	ConditionReady            = "Ready"
+	ServiceBackendReadyReason = "ServiceBackendReady"
+	InvalidTargetReason       = "InvalidTarget"
+
+	ConditionTargetsResolved       = "TargetsResolved"
+	AllTargetsResolvedReason       = "AllTargetsResolved"
+	TargetsPartiallyResolvedReason = "TargetsPartiallyResolved"
+	NoTargetsResolvedReason        = "NoTargetsResolved"
+
+	TargetResolvedReason       = "Resolved"
+	ServiceNotFoundReason      = "ServiceNotFound"
+	PortNotFoundReason         = "PortNotFound"
+	HeadlessServiceReason      = "HeadlessService"
+	NamespaceNotSelectedReason = "NamespaceNotSelected"
+	TargetNotPermittedReason   = "TargetNotPermitted"
+
+	ConditionDegraded          = "Degraded"
+	FailureRatioExceededReason = "FailureRatioExceeded"
+	DeliveriesSucceedingReason = "DeliveriesSucceeding"
+)
+
+// ConditionalResource is a resource with conditions
+type conditionalResource interface {
+	GetStatusConditions() *[]metav1.Condition
+}
+
+// setResourceCondition sets the given condition with the given status,
+// reason and message on a resource.
+func setResourceCondition(resource conditionalResource, condition string, status metav1.ConditionStatus, reason, message string) {
+	conditions := resource.GetStatusConditions()
+
+	newCondition := metav1.Condition{
+		Type:    condition,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+
+	apimeta.SetStatusCondition(conditions, newCondition)
+}
+
+// ReceiverNotReady
+func ReceiverNotReady(clone Receiver, reason, message string) Receiver {
+	setResourceCondition(&clone, ConditionReady, metav1.ConditionFalse, reason, message)
+	return clone
+}
+
+// ReceiverReady
+func ReceiverReady(clone Receiver, reason, message string) Receiver {
+	setResourceCondition(&clone, ConditionReady, metav1.ConditionTrue, reason, message)
+	return clone
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *Receiver) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// GetSpec returns a pointer to the Spec
+func (in *Receiver) GetSpec() *ReceiverSpec {
+	return &in.Spec
+}
+
+// GetStatus returns a pointer to the Status
+func (in *Receiver) GetStatus() *ReceiverStatus {
+	return &in.Status
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=rc
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// Receiver is the Schema for the Receivers API
+type Receiver struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ReceiverSpec   `json:"spec,omitempty"`
+	Status ReceiverStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ReceiverList contains a list of Receiver
+type ReceiverList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Receiver `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Receiver{}, &ReceiverList{})
+}
@@ -0,0 +1,67 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *ClusterReceiver) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// GetSpec returns a pointer to the Spec
+func (in *ClusterReceiver) GetSpec() *ReceiverSpec {
+	return &in.Spec
+}
+
+// GetStatus returns a pointer to the Status
+func (in *ClusterReceiver) GetStatus() *ReceiverStatus {
+	return &in.Status
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=crc
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+// +kubebuilder:validation:XValidation:rule="self.spec.targets.all(t, has(t.namespace) || has(t.namespaceSelector))",message="targets of a ClusterReceiver require a namespace or namespaceSelector"
+
+// ClusterReceiver is the Schema for the cluster scoped ClusterReceivers API.
+// Its targets must define a namespace or namespace selector.
+type ClusterReceiver struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ReceiverSpec   `json:"spec,omitempty"`
+	Status ReceiverStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterReceiverList contains a list of ClusterReceiver
+type ClusterReceiverList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterReceiver `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterReceiver{}, &ClusterReceiverList{})
+}
@@ -0,0 +1,287 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1 "github.com/DoodleScheduling/webhook-controller/api/v1"
+)
+
+// ConvertTo converts this Receiver to the hub version v1
+func (src *Receiver) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1.Receiver)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = convertSpecToV1(src.Spec)
+	dst.Status = convertStatusToV1(src.Status)
+	return nil
+}
+
+// ConvertFrom converts the hub version v1 to this Receiver
+func (dst *Receiver) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1.Receiver)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = convertSpecFromV1(src.Spec)
+	dst.Status = convertStatusFromV1(src.Status)
+	return nil
+}
+
+// ConvertTo converts this ClusterReceiver to the hub version v1
+func (src *ClusterReceiver) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1.ClusterReceiver)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = convertSpecToV1(src.Spec)
+	dst.Status = convertStatusToV1(src.Status)
+	return nil
+}
+
+// ConvertFrom converts the hub version v1 to this ClusterReceiver
+func (dst *ClusterReceiver) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1.ClusterReceiver)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = convertSpecFromV1(src.Spec)
+	dst.Status = convertStatusFromV1(src.Status)
+	return nil
+}
+
+// convertSpecToV1 moves the response settings into spec.response
+func convertSpecToV1(src ReceiverSpec) v1.ReceiverSpec {
+	dst := v1.ReceiverSpec{
+		Suspend: src.Suspend,
+		Response: v1.Response{
+			Type:          v1.ResponseType(src.ResponseType),
+			Quorum:        src.Quorum,
+			PrimaryTarget: src.PrimaryTarget,
+		},
+		BodySizeLimit:       src.BodySizeLimit,
+		BodySizeLimitAction: v1.BodySizeLimitAction(src.BodySizeLimitAction),
+		Streaming:           (*v1.StreamingOptions)(src.Streaming),
+		OrderingKey:         (*v1.RequestKey)(src.OrderingKey),
+		Timeout:             src.Timeout,
+		Targets:             convertSlice(src.Targets, convertTargetToV1),
+	}
+
+	if src.Report != nil {
+		dst.Response.Report = &v1.ReportOptions{
+			StatusCode:    v1.ReportStatusCode(src.Report.StatusCode),
+			BodySizeLimit: src.Report.BodySizeLimit,
+		}
+	}
+
+	return dst
+}
+
+func convertSpecFromV1(src v1.ReceiverSpec) ReceiverSpec {
+	dst := ReceiverSpec{
+		Suspend:             src.Suspend,
+		ResponseType:        ResponseType(src.Response.Type),
+		Quorum:              src.Response.Quorum,
+		PrimaryTarget:       src.Response.PrimaryTarget,
+		BodySizeLimit:       src.BodySizeLimit,
+		BodySizeLimitAction: BodySizeLimitAction(src.BodySizeLimitAction),
+		Streaming:           (*StreamingOptions)(src.Streaming),
+		OrderingKey:         (*RequestKey)(src.OrderingKey),
+		Timeout:             src.Timeout,
+		Targets:             convertSlice(src.Targets, convertTargetFromV1),
+	}
+
+	if src.Response.Report != nil {
+		dst.Report = &ReportOptions{
+			StatusCode:    ReportStatusCode(src.Response.Report.StatusCode),
+			BodySizeLimit: src.Response.Report.BodySizeLimit,
+		}
+	}
+
+	return dst
+}
+
+// convertTargetToV1 replaces the optional port pointers with plain values
+func convertTargetToV1(src Target) v1.Target {
+	dst := v1.Target{
+		Name: src.Name,
+		Path: src.Path,
+		Service: v1.ServiceReference{
+			Name: src.Service.Name,
+			Port: v1.ServicePort{
+				Name:   ptr.Deref(src.Service.Port.Name, ""),
+				Number: ptr.Deref(src.Service.Port.Number, 0),
+			},
+		},
+		ServiceSelector:   src.ServiceSelector,
+		Namespace:         src.Namespace,
+		NamespaceSelector: src.NamespaceSelector,
+		SuccessCodes:      convertSlice(src.SuccessCodes, func(code StatusCodeRange) v1.StatusCodeRange { return v1.StatusCodeRange(code) }),
+		FailureCodes:      convertSlice(src.FailureCodes, func(code StatusCodeRange) v1.StatusCodeRange { return v1.StatusCodeRange(code) }),
+		SuccessExpression: src.SuccessExpression,
+		Retry:             (*v1.RetryPolicy)(src.Retry),
+		Timeout:           src.Timeout,
+		BodySizeLimit:     src.BodySizeLimit,
+		FireAndForget:     src.FireAndForget,
+		Delay:             src.Delay,
+	}
+
+	if src.CircuitBreaker != nil {
+		dst.CircuitBreaker = &v1.CircuitBreakerPolicy{
+			ConsecutiveFailures: src.CircuitBreaker.ConsecutiveFailures,
+			FailurePercentage:   src.CircuitBreaker.FailurePercentage,
+			MinimumDeliveries:   src.CircuitBreaker.MinimumDeliveries,
+			Window:              src.CircuitBreaker.Window,
+			OpenDuration:        src.CircuitBreaker.OpenDuration,
+			HalfOpenProbes:      src.CircuitBreaker.HalfOpenProbes,
+			OpenAction:          v1.CircuitOpenAction(src.CircuitBreaker.OpenAction),
+			QueueTimeout:        src.CircuitBreaker.QueueTimeout,
+			QueueLimit:          src.CircuitBreaker.QueueLimit,
+		}
+	}
+
+	if src.Debounce != nil {
+		dst.Debounce = &v1.DebouncePolicy{
+			Window: src.Debounce.Window,
+			Key:    (*v1.RequestKey)(src.Debounce.Key),
+			Keep:   v1.DebounceKeep(src.Debounce.Keep),
+		}
+	}
+
+	if src.Sampling != nil {
+		dst.Sampling = &v1.SamplingPolicy{
+			Percentage: src.Sampling.Percentage,
+			Key:        (*v1.RequestKey)(src.Sampling.Key),
+		}
+	}
+
+	return dst
+}
+
+func convertTargetFromV1(src v1.Target) Target {
+	dst := Target{
+		Name: src.Name,
+		Path: src.Path,
+		Service: ServiceSelector{
+			Name: src.Service.Name,
+		},
+		ServiceSelector:   src.ServiceSelector,
+		Namespace:         src.Namespace,
+		NamespaceSelector: src.NamespaceSelector,
+		SuccessCodes:      convertSlice(src.SuccessCodes, func(code v1.StatusCodeRange) StatusCodeRange { return StatusCodeRange(code) }),
+		FailureCodes:      convertSlice(src.FailureCodes, func(code v1.StatusCodeRange) StatusCodeRange { return StatusCodeRange(code) }),
+		SuccessExpression: src.SuccessExpression,
+		Retry:             (*RetryPolicy)(src.Retry),
+		Timeout:           src.Timeout,
+		BodySizeLimit:     src.BodySizeLimit,
+		FireAndForget:     src.FireAndForget,
+		Delay:             src.Delay,
+	}
+
+	if src.Service.Port.Name != "" {
+		dst.Service.Port.Name = ptr.To(src.Service.Port.Name)
+	}
+
+	if src.Service.Port.Number != 0 {
+		dst.Service.Port.Number = ptr.To(src.Service.Port.Number)
+	}
+
+	if src.CircuitBreaker != nil {
+		dst.CircuitBreaker = &CircuitBreakerPolicy{
+			ConsecutiveFailures: src.CircuitBreaker.ConsecutiveFailures,
+			FailurePercentage:   src.CircuitBreaker.FailurePercentage,
+			MinimumDeliveries:   src.CircuitBreaker.MinimumDeliveries,
+			Window:              src.CircuitBreaker.Window,
+			OpenDuration:        src.CircuitBreaker.OpenDuration,
+			HalfOpenProbes:      src.CircuitBreaker.HalfOpenProbes,
+			OpenAction:          CircuitOpenAction(src.CircuitBreaker.OpenAction),
+			QueueTimeout:        src.CircuitBreaker.QueueTimeout,
+			QueueLimit:          src.CircuitBreaker.QueueLimit,
+		}
+	}
+
+	if src.Debounce != nil {
+		dst.Debounce = &DebouncePolicy{
+			Window: src.Debounce.Window,
+			Key:    (*RequestKey)(src.Debounce.Key),
+			Keep:   DebounceKeep(src.Debounce.Keep),
+		}
+	}
+
+	if src.Sampling != nil {
+		dst.Sampling = &SamplingPolicy{
+			Percentage: src.Sampling.Percentage,
+			Key:        (*RequestKey)(src.Sampling.Key),
+		}
+	}
+
+	return dst
+}
+
+// convertStatusToV1 renames subResourceCatalog to services
+func convertStatusToV1(src ReceiverStatus) v1.ReceiverStatus {
+	dst := v1.ReceiverStatus{
+		Conditions:         src.Conditions,
+		ObservedGeneration: src.ObservedGeneration,
+		WebhookPath:        src.WebhookPath,
+		Services:           convertSlice(src.SubResourceCatalog, func(ref ResourceReference) v1.ResourceReference { return v1.ResourceReference(ref) }),
+		Targets:            convertSlice(src.Targets, func(target TargetStatus) v1.TargetStatus { return v1.TargetStatus(target) }),
+	}
+
+	if src.Deliveries != nil {
+		dst.Deliveries = &v1.DeliveryStatus{
+			Window:          src.Deliveries.Window,
+			LastRequestTime: src.Deliveries.LastRequestTime,
+			Successful:      src.Deliveries.Successful,
+			Failed:          src.Deliveries.Failed,
+			Targets:         convertSlice(src.Deliveries.Targets, func(target TargetDeliveryStatus) v1.TargetDeliveryStatus { return v1.TargetDeliveryStatus(target) }),
+		}
+	}
+
+	return dst
+}
+
+func convertStatusFromV1(src v1.ReceiverStatus) ReceiverStatus {
+	dst := ReceiverStatus{
+		Conditions:         src.Conditions,
+		ObservedGeneration: src.ObservedGeneration,
+		WebhookPath:        src.WebhookPath,
+		SubResourceCatalog: convertSlice(src.Services, func(ref v1.ResourceReference) ResourceReference { return ResourceReference(ref) }),
+		Targets:            convertSlice(src.Targets, func(target v1.TargetStatus) TargetStatus { return TargetStatus(target) }),
+	}
+
+	if src.Deliveries != nil {
+		dst.Deliveries = &DeliveryStatus{
+			Window:          src.Deliveries.Window,
+			LastRequestTime: src.Deliveries.LastRequestTime,
+			Successful:      src.Deliveries.Successful,
+			Failed:          src.Deliveries.Failed,
+			Targets:         convertSlice(src.Deliveries.Targets, func(target v1.TargetDeliveryStatus) TargetDeliveryStatus { return TargetDeliveryStatus(target) }),
+		}
+	}
+
+	return dst
+}
+
+// convertSlice converts each element while preserving the difference between a nil and an empty slice
+func convertSlice[S, D any](src []S, convert func(S) D) []D {
+	if src == nil {
+		return nil
+	}
+
+	dst := make([]D, 0, len(src))
+	for _, v := range src {
+		dst = append(dst, convert(v))
+	}
+
+	return dst
+}
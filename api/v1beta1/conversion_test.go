@@ -0,0 +1,106 @@
+package v1beta1
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+	"sigs.k8s.io/randfill"
+
+	v1 "github.com/DoodleScheduling/webhook-controller/api/v1"
+)
+
+const fuzzIterations = 1000
+
+// fuzzerFuncs normalizes values which are equivalent in v1 and therefore not preserved by a round trip
+func fuzzerFuncs(_ runtimeserializer.CodecFactory) []interface{} {
+	return []interface{}{
+		func(in *ServicePort, c randfill.Continue) {
+			c.FillNoCustom(in)
+
+			if in.Name != nil && *in.Name == "" {
+				in.Name = nil
+			}
+
+			if in.Number != nil && *in.Number == 0 {
+				in.Number = nil
+			}
+		},
+	}
+}
+
+func newFiller(t *testing.T) *randfill.Filler {
+	scheme := runtime.NewScheme()
+	NewWithT(t).Expect(AddToScheme(scheme)).To(Succeed())
+	NewWithT(t).Expect(v1.AddToScheme(scheme)).To(Succeed())
+
+	funcs := fuzzer.MergeFuzzerFuncs(metafuzzer.Funcs, fuzzerFuncs)
+	return fuzzer.FuzzerFor(funcs, rand.NewSource(rand.Int63()), runtimeserializer.NewCodecFactory(scheme))
+}
+
+func TestConversionRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		spoke func() conversion.Convertible
+		hub   func() conversion.Hub
+	}{
+		{
+			name:  "Receiver",
+			spoke: func() conversion.Convertible { return &Receiver{} },
+			hub:   func() conversion.Hub { return &v1.Receiver{} },
+		},
+		{
+			name:  "ClusterReceiver",
+			spoke: func() conversion.Convertible { return &ClusterReceiver{} },
+			hub:   func() conversion.Hub { return &v1.ClusterReceiver{} },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name+" spoke-hub-spoke", func(t *testing.T) {
+			g := NewWithT(t)
+			filler := newFiller(t)
+
+			for i := 0; i < fuzzIterations; i++ {
+				spoke := test.spoke()
+				filler.Fill(spoke)
+				spoke.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{})
+
+				hub := test.hub()
+				g.Expect(spoke.ConvertTo(hub)).To(Succeed())
+
+				converted := test.spoke()
+				g.Expect(converted.ConvertFrom(hub)).To(Succeed())
+
+				g.Expect(apiequality.Semantic.DeepEqual(spoke, converted)).To(BeTrue(), diff.Diff(spoke, converted))
+			}
+		})
+
+		t.Run(test.name+" hub-spoke-hub", func(t *testing.T) {
+			g := NewWithT(t)
+			filler := newFiller(t)
+
+			for i := 0; i < fuzzIterations; i++ {
+				hub := test.hub()
+				filler.Fill(hub)
+				hub.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{})
+
+				spoke := test.spoke()
+				g.Expect(spoke.ConvertFrom(hub)).To(Succeed())
+
+				converted := test.hub()
+				g.Expect(spoke.ConvertTo(converted)).To(Succeed())
+
+				g.Expect(apiequality.Semantic.DeepEqual(hub, converted)).To(BeTrue(), diff.Diff(hub, converted))
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WebhookTargetGrantSpec defines which Receivers may forward webhooks to services in the namespace of the grant
+type WebhookTargetGrantSpec struct {
+	// From lists the namespaces of the Receivers which are permitted to target services in this namespace
+	// +kubebuilder:validation:MinItems=1
+	From []WebhookTargetGrantFrom `json:"from"`
+
+	// To lists the services which may be targeted, all services of the namespace if empty
+	// +optional
+	To []WebhookTargetGrantTo `json:"to,omitempty"`
+}
+
+type WebhookTargetGrantFrom struct {
+	// Namespace of the Receivers
+	Namespace string `json:"namespace"`
+}
+
+type WebhookTargetGrantTo struct {
+	// Name of the service
+	Name string `json:"name"`
+}
+
+// Permits reports whether the grant permits Receivers from the given namespace to target the named service
+func (in *WebhookTargetGrant) Permits(namespace, service string) bool {
+	if !slices.ContainsFunc(in.Spec.From, func(from WebhookTargetGrantFrom) bool {
+		return from.Namespace == namespace
+	}) {
+		return false
+	}
+
+	return len(in.Spec.To) == 0 || slices.ContainsFunc(in.Spec.To, func(to WebhookTargetGrantTo) bool {
+		return to.Name == service
+	})
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=wtg
+
+// WebhookTargetGrant permits Receivers from other namespaces to forward webhooks to services in its namespace
+type WebhookTargetGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WebhookTargetGrantSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// WebhookTargetGrantList contains a list of WebhookTargetGrant
+type WebhookTargetGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WebhookTargetGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WebhookTargetGrant{}, &WebhookTargetGrantList{})
+}
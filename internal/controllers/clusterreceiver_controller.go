@@ -0,0 +1,95 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// +kubebuilder:rbac:groups=webhook.infra.doodle.com,resources=clusterreceivers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=webhook.infra.doodle.com,resources=clusterreceivers/status,verbs=get;update;patch
+
+package controllers
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "github.com/DoodleScheduling/webhook-controller/api/v1"
+)
+
+// ClusterReceiverReconciler reconciles a ClusterReceiver object.
+// ClusterReceivers are registered in the same proxy as Receivers and share their reconciliation.
+type ClusterReceiverReconciler struct {
+	ReceiverReconciler
+}
+
+// SetupWithManager adding controllers.
+// The controller runs on every replica since each of them serves webhooks, only the leader writes the status.
+func (r *ClusterReceiverReconciler) SetupWithManager(mgr ctrl.Manager, opts ReceiverReconcilerOptions) error {
+	r.cache = mgr.GetCache()
+	r.elected = mgr.Elected()
+	r.newList = func() client.ObjectList {
+		return &infrav1.ClusterReceiverList{}
+	}
+
+	if err := indexReceivers(mgr, &infrav1.ClusterReceiver{}); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.ClusterReceiver{}, builder.WithPredicates(
+			predicate.Or(predicate.GenerationChangedPredicate{}, webhookPathChangedPredicate),
+		)).
+		Watches(
+			&v1.Service{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForChangeBySelector),
+		).
+		Watches(
+			&v1.Namespace{},
+			r.namespaceHandler(),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}),
+		).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: opts.MaxConcurrentReconciles,
+			NeedLeaderElection:      ptr.To(false),
+		}).
+		Complete(r)
+}
+
+// Reconcile ClusterReceivers
+func (r *ClusterReceiverReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Log.WithValues("Name", req.Name)
+	logger.Info("reconciling ClusterReceiver")
+
+	receiver := infrav1.ClusterReceiver{}
+
+	err := r.Get(ctx, req.NamespacedName, &receiver)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, r.HttpProxy.UnregisterOwner("", req.Name)
+		}
+
+		return reconcile.Result{}, err
+	}
+
+	return r.reconcileReceiver(ctx, &receiver, logger)
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/DoodleScheduling/webhook-controller/api/v1"
+	"github.com/DoodleScheduling/webhook-controller/internal/proxy"
+)
+
+func TestClusterReceiverReconciler_Reconcile(t *testing.T) {
+	portName := "http"
+	testScheme := runtime.NewScheme()
+	NewWithT(t).Expect(clientgoscheme.AddToScheme(testScheme)).To(Succeed())
+	NewWithT(t).Expect(infrav1.AddToScheme(testScheme)).To(Succeed())
+
+	tests := []struct {
+		name              string
+		targets           []infrav1.Target
+		expectRegistered  bool
+		expectedCondition metav1.Condition
+	}{
+		{
+			name: "Targets in any namespace are registered without a grant",
+			targets: []infrav1.Target{
+				{
+					Namespace: "team-a",
+					Service:   infrav1.ServiceReference{Name: "podinfo", Port: infrav1.ServicePort{Name: portName}},
+				},
+			},
+			expectRegistered: true,
+			expectedCondition: metav1.Condition{
+				Type:    infrav1.ConditionReady,
+				Status:  metav1.ConditionTrue,
+				Reason:  infrav1.ServiceBackendReadyReason,
+				Message: "receiver successfully registered",
+			},
+		},
+		{
+			name: "Targets without a namespace are invalid",
+			targets: []infrav1.Target{
+				{
+					Service: infrav1.ServiceReference{Name: "podinfo", Port: infrav1.ServicePort{Name: portName}},
+				},
+			},
+			expectedCondition: metav1.Condition{
+				Type:    infrav1.ConditionReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  infrav1.InvalidTargetReason,
+				Message: "invalid target 0: either namespace or namespaceSelector is required",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			receiver := &infrav1.ClusterReceiver{
+				ObjectMeta: metav1.ObjectMeta{Name: "github", UID: "1234"},
+				Spec:       infrav1.ReceiverSpec{Targets: test.targets},
+			}
+
+			c := fake.NewClientBuilder().
+				WithScheme(testScheme).
+				WithObjects(
+					receiver,
+					&v1.Service{
+						ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "team-a"},
+						Spec: v1.ServiceSpec{
+							ClusterIP: "10.0.0.1",
+							Ports:     []v1.ServicePort{{Name: portName, Port: 9898}},
+						},
+					},
+				).
+				WithStatusSubresource(&infrav1.ClusterReceiver{}).
+				Build()
+
+			httpProxy := proxy.New(proxy.DefaultOptions)
+			r := &ClusterReceiverReconciler{
+				ReceiverReconciler: ReceiverReconciler{
+					Client:    c,
+					HttpProxy: httpProxy,
+					Log:       logr.Discard(),
+					Recorder:  record.NewFakeRecorder(10),
+				},
+			}
+
+			_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(receiver)})
+			g.Expect(err).NotTo(HaveOccurred())
+
+			g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(receiver), receiver)).To(Succeed())
+			condition := apimeta.FindStatusCondition(receiver.Status.Conditions, infrav1.ConditionReady)
+			g.Expect(condition).NotTo(BeNil())
+			condition.LastTransitionTime = metav1.Time{}
+			g.Expect(*condition).To(Equal(test.expectedCondition))
+			g.Expect(httpProxy.IsRegistered(receiver.Status.WebhookPath)).To(Equal(test.expectRegistered))
+
+			g.Expect(c.Delete(context.Background(), receiver)).To(Succeed())
+			_, err = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(receiver)})
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(httpProxy.IsRegistered(receiver.Status.WebhookPath)).To(BeFalse(), "deleted receivers are unregistered")
+		})
+	}
+}
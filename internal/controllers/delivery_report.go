@@ -0,0 +1,189 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/DoodleScheduling/webhook-controller/internal/proxy"
+)
+
+const (
+	// deliveryReportLabel selects the delivery reports of all replicas, its value is the id of the controller
+	deliveryReportLabel = "webhook.infra.doodle.com/delivery-report"
+
+	// deliveryReportKey is the ConfigMap key holding the report
+	deliveryReportKey = "report.json"
+
+	// deliveryReportStaleIntervals is the number of intervals after which the report of a running replica is considered missing
+	deliveryReportStaleIntervals = 3
+)
+
+type deliveryStatsProvider interface {
+	DeliveryStats() map[types.UID]proxy.ReceiverStats
+}
+
+// deliveryReport holds the delivery statistics of a single replica
+type deliveryReport struct {
+	Replica     string                            `json:"replica"`
+	PublishTime time.Time                         `json:"publishTime"`
+	Stopped     bool                              `json:"stopped,omitempty"`
+	Receivers   map[types.UID]proxy.ReceiverStats `json:"receivers,omitempty"`
+}
+
+// DeliveryReportPublisher periodically publishes the delivery statistics of the replica in a ConfigMap.
+// It runs on every replica, the DeliveryStatusUpdater of the leader aggregates the reports of all replicas.
+type DeliveryReportPublisher struct {
+	client.Client
+	Stats    deliveryStatsProvider
+	Log      logr.Logger
+	Interval time.Duration
+
+	// Namespace the report is published in
+	Namespace string
+	// Replica is the unique name of the replica, usually the pod name
+	Replica string
+	// ID identifies the reports of all replicas of the controller
+	ID string
+}
+
+// Start publishes the delivery report once per interval until the context is done
+func (p *DeliveryReportPublisher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// The final report keeps the deliveries of a stopped replica until they left the window
+			stopCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			if err := p.publish(stopCtx, true); err != nil {
+				p.Log.Error(err, "failed to publish final delivery report")
+			}
+
+			return nil
+		case <-ticker.C:
+			if err := p.publish(ctx, false); err != nil {
+				p.Log.Error(err, "failed to publish delivery report")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection returns false since every replica publishes its own report
+func (p *DeliveryReportPublisher) NeedLeaderElection() bool {
+	return false
+}
+
+func (p *DeliveryReportPublisher) publish(ctx context.Context, stopped bool) error {
+	report, err := json.Marshal(deliveryReport{
+		Replica:     p.Replica,
+		PublishTime: time.Now(),
+		Stopped:     stopped,
+		Receivers:   p.Stats.DeliveryStats(),
+	})
+	if err != nil {
+		return err
+	}
+
+	configMap := corev1ac.ConfigMap(fmt.Sprintf("%s-%s", p.ID, p.Replica), p.Namespace).
+		WithLabels(map[string]string{deliveryReportLabel: p.ID}).
+		WithData(map[string]string{deliveryReportKey: string(report)})
+
+	return p.Apply(ctx, configMap, client.FieldOwner("webhook-controller"), client.ForceOwnership)
+}
+
+// aggregateReports sums the delivery statistics of all replicas per receiver
+func aggregateReports(reports []deliveryReport) map[types.UID]proxy.ReceiverStats {
+	stats := make(map[types.UID]proxy.ReceiverStats)
+	for _, report := range reports {
+		for owner, replicaStats := range report.Receivers {
+			stats[owner] = mergeStats(stats[owner], replicaStats)
+		}
+	}
+
+	return stats
+}
+
+// mergeStats adds the statistics of a replica to the statistics of the other replicas.
+// Each replica has its own circuit breakers, the most open circuit state of a target is kept.
+func mergeStats(stats, replica proxy.ReceiverStats) proxy.ReceiverStats {
+	stats.LastRequestTime = latest(stats.LastRequestTime, replica.LastRequestTime)
+	stats.Successful += replica.Successful
+	stats.Failed += replica.Failed
+
+	for _, target := range replica.Targets {
+		i := slices.IndexFunc(stats.Targets, func(t proxy.TargetStats) bool {
+			return t.Service == target.Service && t.Namespace == target.Namespace && t.Port == target.Port
+		})
+
+		if i == -1 {
+			stats.Targets = append(stats.Targets, target)
+			continue
+		}
+
+		merged := &stats.Targets[i]
+		if target.LastRequestTime.After(merged.LastRequestTime) {
+			merged.LastStatusCode = target.LastStatusCode
+		}
+
+		merged.LastRequestTime = latest(merged.LastRequestTime, target.LastRequestTime)
+		merged.LastSuccessTime = latest(merged.LastSuccessTime, target.LastSuccessTime)
+		merged.LastFailureTime = latest(merged.LastFailureTime, target.LastFailureTime)
+		merged.Successful += target.Successful
+		merged.Failed += target.Failed
+
+		if circuitStateOrder(target.CircuitState) > circuitStateOrder(merged.CircuitState) {
+			merged.CircuitState = target.CircuitState
+		}
+	}
+
+	slices.SortFunc(stats.Targets, func(a, b proxy.TargetStats) int {
+		return cmp.Or(
+			cmp.Compare(a.Namespace, b.Namespace),
+			cmp.Compare(a.Service, b.Service),
+			cmp.Compare(a.Port, b.Port),
+		)
+	})
+
+	return stats
+}
+
+// circuitStateOrder orders the circuit states from closed to open, targets without a circuit breaker come first
+func circuitStateOrder(state proxy.CircuitState) int {
+	return slices.Index([]proxy.CircuitState{"", proxy.CircuitClosed, proxy.CircuitHalfOpen, proxy.CircuitOpen}, state)
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+
+	return a
+}
@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/DoodleScheduling/webhook-controller/api/v1"
+	"github.com/DoodleScheduling/webhook-controller/internal/proxy"
+)
+
+type fakeDeliveryStats map[types.UID]proxy.ReceiverStats
+
+func (s fakeDeliveryStats) DeliveryStats() map[types.UID]proxy.ReceiverStats {
+	return s
+}
+
+func TestAggregateReports(t *testing.T) {
+	g := NewWithT(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	stats := aggregateReports([]deliveryReport{
+		{
+			Replica: "a",
+			Receivers: map[types.UID]proxy.ReceiverStats{
+				"uid": {
+					LastRequestTime: now,
+					Successful:      3,
+					Failed:          1,
+					Targets: []proxy.TargetStats{
+						{Service: "podinfo", Namespace: "default", Port: 9898, LastRequestTime: now, LastFailureTime: now, LastStatusCode: 503, Successful: 2, Failed: 1, CircuitState: proxy.CircuitClosed},
+						{Service: "echo", Namespace: "default", Port: 80, LastRequestTime: now, LastSuccessTime: now, LastStatusCode: 200, Successful: 1},
+					},
+				},
+			},
+		},
+		{
+			Replica: "b",
+			Receivers: map[types.UID]proxy.ReceiverStats{
+				"uid": {
+					LastRequestTime: now.Add(time.Minute),
+					Successful:      1,
+					Failed:          4,
+					Targets: []proxy.TargetStats{
+						{Service: "podinfo", Namespace: "default", Port: 9898, LastRequestTime: now.Add(time.Minute), LastSuccessTime: now.Add(time.Minute), LastFailureTime: now.Add(-time.Minute), LastStatusCode: 200, Successful: 1, Failed: 4, CircuitState: proxy.CircuitOpen},
+					},
+				},
+				"other": {
+					Successful: 1,
+				},
+			},
+		},
+	})
+
+	g.Expect(stats).To(Equal(map[types.UID]proxy.ReceiverStats{
+		"uid": {
+			LastRequestTime: now.Add(time.Minute),
+			Successful:      4,
+			Failed:          5,
+			Targets: []proxy.TargetStats{
+				{Service: "echo", Namespace: "default", Port: 80, LastRequestTime: now, LastSuccessTime: now, LastStatusCode: 200, Successful: 1},
+				{Service: "podinfo", Namespace: "default", Port: 9898, LastRequestTime: now.Add(time.Minute), LastSuccessTime: now.Add(time.Minute), LastFailureTime: now, LastStatusCode: 200, Successful: 3, Failed: 5, CircuitState: proxy.CircuitOpen},
+			},
+		},
+		"other": {
+			Successful: 1,
+		},
+	}))
+}
+
+func TestDeliveryStatusUpdater(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	NewWithT(t).Expect(clientgoscheme.AddToScheme(testScheme)).To(Succeed())
+	NewWithT(t).Expect(infrav1.AddToScheme(testScheme)).To(Succeed())
+
+	now := time.Now()
+	replicaStats := func(successful, failed int64) fakeDeliveryStats {
+		return fakeDeliveryStats{
+			"uid": {
+				LastRequestTime: now,
+				Successful:      successful,
+				Failed:          failed,
+				Targets: []proxy.TargetStats{
+					{Service: "podinfo", Namespace: "default", Port: 9898, LastRequestTime: now, Successful: successful, Failed: failed},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name               string
+		reports            []deliveryReport
+		expectedSuccessful int64
+		expectedFailed     int64
+		expectedDegraded   metav1.ConditionStatus
+		expectedDeleted    []string
+	}{
+		{
+			name:               "Aggregates the reports of all replicas",
+			expectedSuccessful: 4,
+			expectedFailed:     5,
+			expectedDegraded:   metav1.ConditionTrue,
+		},
+		{
+			name: "Keeps the Degraded condition while the report of a running replica is outdated",
+			reports: []deliveryReport{
+				{Replica: "c", PublishTime: now.Add(-4 * time.Minute), Receivers: replicaStats(10, 0)},
+			},
+			expectedSuccessful: 14,
+			expectedFailed:     5,
+		},
+		{
+			name: "Includes the final report of a stopped replica",
+			reports: []deliveryReport{
+				{Replica: "c", PublishTime: now.Add(-4 * time.Minute), Stopped: true, Receivers: replicaStats(10, 0)},
+			},
+			expectedSuccessful: 14,
+			expectedFailed:     5,
+			expectedDegraded:   metav1.ConditionFalse,
+		},
+		{
+			name: "Deletes reports without deliveries within the window",
+			reports: []deliveryReport{
+				{Replica: "c", PublishTime: now.Add(-6 * time.Minute), Receivers: replicaStats(10, 0)},
+			},
+			expectedSuccessful: 4,
+			expectedFailed:     5,
+			expectedDegraded:   metav1.ConditionTrue,
+			expectedDeleted:    []string{"webhook-controller-c"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			receiver := &infrav1.Receiver{
+				ObjectMeta: metav1.ObjectMeta{Name: "receiver", Namespace: "default", UID: "uid"},
+			}
+
+			objects := []client.Object{receiver}
+			for _, report := range test.reports {
+				data, err := json.Marshal(report)
+				g.Expect(err).NotTo(HaveOccurred())
+
+				objects = append(objects, &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "webhook-controller-" + report.Replica,
+						Namespace: "controller",
+						Labels:    map[string]string{deliveryReportLabel: "webhook-controller"},
+					},
+					Data: map[string]string{deliveryReportKey: string(data)},
+				})
+			}
+
+			c := fake.NewClientBuilder().
+				WithScheme(testScheme).
+				WithObjects(objects...).
+				WithStatusSubresource(receiver).
+				Build()
+
+			for replica, stats := range map[string]fakeDeliveryStats{"a": replicaStats(3, 1), "b": replicaStats(1, 4)} {
+				publisher := &DeliveryReportPublisher{
+					Client:    c,
+					Stats:     stats,
+					Log:       logr.Discard(),
+					Interval:  time.Minute,
+					Namespace: "controller",
+					Replica:   replica,
+					ID:        "webhook-controller",
+				}
+
+				g.Expect(publisher.publish(ctx, false)).To(Succeed())
+			}
+
+			updater := &DeliveryStatusUpdater{
+				Client:               c,
+				Log:                  logr.Discard(),
+				Interval:             time.Minute,
+				Window:               5 * time.Minute,
+				Namespace:            "controller",
+				ID:                   "webhook-controller",
+				DegradedFailureRatio: 0.5,
+			}
+
+			g.Expect(updater.update(ctx)).To(Succeed())
+			g.Expect(c.Get(ctx, client.ObjectKeyFromObject(receiver), receiver)).To(Succeed())
+
+			g.Expect(receiver.Status.Deliveries).NotTo(BeNil())
+			g.Expect(receiver.Status.Deliveries.Successful).To(Equal(test.expectedSuccessful))
+			g.Expect(receiver.Status.Deliveries.Failed).To(Equal(test.expectedFailed))
+
+			degraded := apimeta.FindStatusCondition(receiver.Status.Conditions, infrav1.ConditionDegraded)
+			if test.expectedDegraded == "" {
+				g.Expect(degraded).To(BeNil())
+			} else {
+				g.Expect(degraded).NotTo(BeNil())
+				g.Expect(degraded.Status).To(Equal(test.expectedDegraded))
+			}
+
+			for _, name := range test.expectedDeleted {
+				err := c.Get(ctx, client.ObjectKey{Name: name, Namespace: "controller"}, &corev1.ConfigMap{})
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "report %s is deleted", name)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/DoodleScheduling/webhook-controller/api/v1"
+	"github.com/DoodleScheduling/webhook-controller/internal/proxy"
+)
+
+// DeliveryStatusUpdater periodically writes the delivery statistics of all replicas to the Receiver and ClusterReceiver status.
+// It aggregates the reports published by the DeliveryReportPublisher of each replica.
+// It only runs on the leader, the Receiver controller ignores these status updates.
+type DeliveryStatusUpdater struct {
+	client.Client
+	Log      logr.Logger
+	Interval time.Duration
+
+	// Window is the rolling window the replicas calculate the delivery counts over
+	Window time.Duration
+	// Namespace the reports are published in
+	Namespace string
+	// ID identifies the reports of all replicas of the controller
+	ID string
+
+	// DegradedFailureRatio marks a Receiver as Degraded if the ratio of failed deliveries of any target exceeds it, 0 disables the condition
+	DegradedFailureRatio float64
+}
+
+// Start updates the status of all Receivers with delivery statistics once per interval until the context is done
+func (u *DeliveryStatusUpdater) Start(ctx context.Context) error {
+	ticker := time.NewTicker(u.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := u.update(ctx); err != nil {
+				u.Log.Error(err, "failed to update delivery status")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection returns true since only the leader writes the status
+func (u *DeliveryStatusUpdater) NeedLeaderElection() bool {
+	return true
+}
+
+func (u *DeliveryStatusUpdater) update(ctx context.Context) error {
+	reports, complete, err := u.reports(ctx)
+	if err != nil {
+		return err
+	}
+
+	stats := aggregateReports(reports)
+
+	// The Degraded condition is kept as is while the report of a running replica is missing
+	degradedFailureRatio := u.DegradedFailureRatio
+	if !complete {
+		degradedFailureRatio = 0
+	}
+
+	var receivers infrav1.ReceiverList
+	if err := u.List(ctx, &receivers); err != nil {
+		return err
+	}
+
+	var clusterReceivers infrav1.ClusterReceiverList
+	if err := u.List(ctx, &clusterReceivers); err != nil {
+		return err
+	}
+
+	var objects []receiverObject
+	for i := range receivers.Items {
+		objects = append(objects, &receivers.Items[i])
+	}
+
+	for i := range clusterReceivers.Items {
+		objects = append(objects, &clusterReceivers.Items[i])
+	}
+
+	for _, receiver := range objects {
+		receiverStats, ok := stats[receiver.GetUID()]
+		if !ok {
+			continue
+		}
+
+		updated := receiver.DeepCopyObject().(receiverObject)
+		setDeliveryStatus(updated, receiverStats, u.Window, degradedFailureRatio)
+		if apiequality.Semantic.DeepEqual(updated.GetStatus(), receiver.GetStatus()) {
+			continue
+		}
+
+		// The optimistic lock makes sure conditions written by the Receiver controller in the meantime are not reverted
+		patch := client.MergeFromWithOptions(receiver, client.MergeFromWithOptimisticLock{})
+		if err := u.Status().Patch(ctx, updated, patch); err != nil {
+			u.Log.Error(err, "failed to update delivery status", "namespace", receiver.GetNamespace(), "name", receiver.GetName())
+		}
+	}
+
+	return nil
+}
+
+// reports returns the delivery reports of all replicas, reports which no longer hold deliveries within the window are deleted.
+// It returns false if the report of a running replica is outdated or can not be decoded.
+func (u *DeliveryStatusUpdater) reports(ctx context.Context) ([]deliveryReport, bool, error) {
+	var configMaps corev1.ConfigMapList
+	if err := u.List(ctx, &configMaps, client.InNamespace(u.Namespace), client.MatchingLabels{deliveryReportLabel: u.ID}); err != nil {
+		return nil, false, err
+	}
+
+	var reports []deliveryReport
+	complete := true
+	now := time.Now()
+
+	for i := range configMaps.Items {
+		configMap := &configMaps.Items[i]
+
+		var report deliveryReport
+		if err := json.Unmarshal([]byte(configMap.Data[deliveryReportKey]), &report); err != nil {
+			u.Log.Error(err, "failed to decode delivery report", "name", configMap.Name)
+			complete = false
+			continue
+		}
+
+		switch age := now.Sub(report.PublishTime); {
+		case age > u.Window:
+			if err := u.Delete(ctx, configMap); client.IgnoreNotFound(err) != nil {
+				u.Log.Error(err, "failed to delete delivery report", "name", configMap.Name)
+			}
+
+			continue
+		case age > deliveryReportStaleIntervals*u.Interval && !report.Stopped:
+			complete = false
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, complete, nil
+}
+
+// setDeliveryStatus sets the delivery statistics and the Degraded condition
+func setDeliveryStatus(receiver receiverObject, stats proxy.ReceiverStats, window time.Duration, degradedFailureRatio float64) {
+	status := &infrav1.DeliveryStatus{
+		Window:          metav1.Duration{Duration: window},
+		LastRequestTime: statusTime(stats.LastRequestTime),
+		Successful:      stats.Successful,
+		Failed:          stats.Failed,
+	}
+
+	var degraded []string
+	for _, target := range stats.Targets {
+		status.Targets = append(status.Targets, infrav1.TargetDeliveryStatus{
+			Name:            target.Service,
+			Namespace:       target.Namespace,
+			Port:            target.Port,
+			LastRequestTime: statusTime(target.LastRequestTime),
+			LastSuccessTime: statusTime(target.LastSuccessTime),
+			LastFailureTime: statusTime(target.LastFailureTime),
+			LastStatusCode:  int32(target.LastStatusCode),
+			Successful:      target.Successful,
+			Failed:          target.Failed,
+			CircuitState:    string(target.CircuitState),
+		})
+
+		total := target.Successful + target.Failed
+		if degradedFailureRatio > 0 && target.Failed > 0 && float64(target.Failed)/float64(total) > degradedFailureRatio {
+			degraded = append(degraded, fmt.Sprintf("target %s/%s:%d failed %d of %d deliveries in last %s",
+				target.Namespace, target.Service, target.Port, target.Failed, total, duration.HumanDuration(window)))
+		}
+	}
+
+	receiver.GetStatus().Deliveries = status
+
+	switch {
+	case degradedFailureRatio <= 0:
+	case len(degraded) > 0:
+		setCondition(receiver, infrav1.ConditionDegraded, metav1.ConditionTrue, infrav1.FailureRatioExceededReason, strings.Join(degraded, ", "))
+	default:
+		setCondition(receiver, infrav1.ConditionDegraded, metav1.ConditionFalse, infrav1.DeliveriesSucceedingReason,
+			fmt.Sprintf("failure ratio of all targets is below %g", degradedFailureRatio))
+	}
+}
+
+// statusTime truncates the time to the precision stored in the status, zero times are omitted
+func statusTime(t time.Time) *metav1.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &metav1.Time{Time: t.Truncate(time.Second)}
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrav1 "github.com/DoodleScheduling/webhook-controller/api/v1"
+	"github.com/DoodleScheduling/webhook-controller/internal/proxy"
+)
+
+func TestSetDeliveryStatus(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 500, time.UTC)
+	stats := proxy.ReceiverStats{
+		LastRequestTime: now,
+		Successful:      9,
+		Failed:          7,
+		Targets: []proxy.TargetStats{
+			{
+				Service:         "echo",
+				Namespace:       "default",
+				Port:            80,
+				LastRequestTime: now,
+				LastSuccessTime: now,
+				LastStatusCode:  200,
+				Successful:      8,
+				Failed:          2,
+			},
+			{
+				Service:         "podinfo",
+				Namespace:       "default",
+				Port:            9898,
+				LastRequestTime: now,
+				LastSuccessTime: now.Add(-time.Minute),
+				LastFailureTime: now,
+				LastStatusCode:  503,
+				Successful:      1,
+				Failed:          5,
+				CircuitState:    proxy.CircuitOpen,
+			},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		failureRatio      float64
+		expectedCondition *metav1.Condition
+	}{
+		{
+			name:         "Degraded if the failure ratio of a target is exceeded",
+			failureRatio: 0.5,
+			expectedCondition: &metav1.Condition{
+				Type:    infrav1.ConditionDegraded,
+				Status:  metav1.ConditionTrue,
+				Reason:  infrav1.FailureRatioExceededReason,
+				Message: "target default/podinfo:9898 failed 5 of 6 deliveries in last 5m",
+			},
+		},
+		{
+			name:         "Not degraded if all targets are below the failure ratio",
+			failureRatio: 0.9,
+			expectedCondition: &metav1.Condition{
+				Type:    infrav1.ConditionDegraded,
+				Status:  metav1.ConditionFalse,
+				Reason:  infrav1.DeliveriesSucceedingReason,
+				Message: "failure ratio of all targets is below 0.9",
+			},
+		},
+		{
+			name: "No Degraded condition if disabled",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			receiver := infrav1.ReceiverReady(infrav1.Receiver{}, infrav1.ServiceBackendReadyReason, "receiver successfully registered")
+
+			updated := receiver.DeepCopy()
+			setDeliveryStatus(updated, stats, 5*time.Minute, test.failureRatio)
+
+			lastRequestTime := &metav1.Time{Time: now.Truncate(time.Second)}
+			g.Expect(updated.Status.Deliveries).To(Equal(&infrav1.DeliveryStatus{
+				Window:          metav1.Duration{Duration: 5 * time.Minute},
+				LastRequestTime: lastRequestTime,
+				Successful:      9,
+				Failed:          7,
+				Targets: []infrav1.TargetDeliveryStatus{
+					{
+						Name:            "echo",
+						Namespace:       "default",
+						Port:            80,
+						LastRequestTime: lastRequestTime,
+						LastSuccessTime: lastRequestTime,
+						LastStatusCode:  200,
+						Successful:      8,
+						Failed:          2,
+					},
+					{
+						Name:            "podinfo",
+						Namespace:       "default",
+						Port:            9898,
+						LastRequestTime: lastRequestTime,
+						LastSuccessTime: &metav1.Time{Time: now.Add(-time.Minute).Truncate(time.Second)},
+						LastFailureTime: lastRequestTime,
+						LastStatusCode:  503,
+						Successful:      1,
+						Failed:          5,
+						CircuitState:    "Open",
+					},
+				},
+			}))
+
+			g.Expect(apimeta.IsStatusConditionTrue(updated.Status.Conditions, infrav1.ConditionReady)).To(BeTrue(), "other conditions are kept")
+
+			degraded := apimeta.FindStatusCondition(updated.Status.Conditions, infrav1.ConditionDegraded)
+			if test.expectedCondition == nil {
+				g.Expect(degraded).To(BeNil())
+				return
+			}
+
+			g.Expect(degraded).NotTo(BeNil())
+			degraded.LastTransitionTime = metav1.Time{}
+			g.Expect(*degraded).To(Equal(*test.expectedCondition))
+		})
+	}
+}
@@ -19,6 +19,7 @@ limitations under the License.
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups=webhook.infra.doodle.com,resources=receivers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=webhook.infra.doodle.com,resources=receivers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=webhook.infra.doodle.com,resources=webhooktargetgrants,verbs=get;list;watch
 
 package controllers
 
@@ -27,21 +28,32 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"slices"
+	"strconv"
+	"time"
 
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	v1beta1 "github.com/DoodleScheduling/webhook-controller/api/v1beta1"
+	infrav1 "github.com/DoodleScheduling/webhook-controller/api/v1"
 	"github.com/DoodleScheduling/webhook-controller/internal/proxy"
 )
 
@@ -51,29 +63,318 @@ type ReceiverReconciler struct {
 	HttpProxy pathUpdater
 	Log       logr.Logger
 	Recorder  record.EventRecorder
+
+	// AllowCrossNamespaceTargets disables the WebhookTargetGrant check for services in other namespaces than the Receiver
+	AllowCrossNamespaceTargets bool
+
+	cache   cache.Cache
+	elected <-chan struct{}
+
+	// newList returns an empty list of the reconciled kind, Receivers if nil
+	newList func() client.ObjectList
+}
+
+// receiverObject is either a Receiver or a ClusterReceiver
+type receiverObject interface {
+	client.Object
+	GetSpec() *infrav1.ReceiverSpec
+	GetStatus() *infrav1.ReceiverStatus
 }
 
 type pathUpdater interface {
 	RegisterOrUpdate(receiver proxy.Receiver) error
 	Unregister(path string) error
+	UnregisterOwner(namespace, name string) error
+	IsRegistered(path string) bool
 }
 
+const (
+	// followerRequeueInterval is used by replicas which are not the leader to wait for the leader to assign a webhook path
+	followerRequeueInterval = 10 * time.Second
+
+	// cacheSyncTimeout is the time the readiness check waits for the cache to sync
+	cacheSyncTimeout = time.Second
+)
+
 type ReceiverReconcilerOptions struct {
 	MaxConcurrentReconciles int
 }
 
-// SetupWithManager adding controllers
+const (
+	// targetServiceIndex indexes Receivers by the names of their target services
+	targetServiceIndex = ".spec.targets.service.name"
+
+	// serviceSelectorIndex indexes Receivers which select target services by labels
+	serviceSelectorIndex = ".spec.targets.serviceSelector"
+
+	// namespaceSelectorIndex indexes Receivers which select target namespaces by labels
+	namespaceSelectorIndex = ".spec.targets.namespaceSelector"
+
+	// targetNamespaceIndex indexes Receivers by the namespaces of their targets
+	targetNamespaceIndex = ".spec.targets.namespace"
+)
+
+// SetupWithManager adding controllers.
+// The controller runs on every replica since each of them serves webhooks, only the leader writes the status.
 func (r *ReceiverReconciler) SetupWithManager(mgr ctrl.Manager, opts ReceiverReconcilerOptions) error {
+	r.cache = mgr.GetCache()
+	r.elected = mgr.Elected()
+
+	if err := indexReceivers(mgr, &infrav1.Receiver{}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1beta1.Receiver{}).
+		For(&infrav1.Receiver{}, builder.WithPredicates(
+			// Status updates such as the delivery statistics are ignored except the webhook path
+			// which is assigned by the leader and registered by all replicas
+			predicate.Or(predicate.GenerationChangedPredicate{}, webhookPathChangedPredicate),
+		)).
 		Watches(
 			&v1.Service{},
 			handler.EnqueueRequestsFromMapFunc(r.requestsForChangeBySelector),
 		).
-		WithOptions(controller.Options{MaxConcurrentReconciles: opts.MaxConcurrentReconciles}).
+		Watches(
+			&v1.Namespace{},
+			r.namespaceHandler(),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}),
+		).
+		Watches(
+			&infrav1.WebhookTargetGrant{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForGrant),
+		).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: opts.MaxConcurrentReconciles,
+			NeedLeaderElection:      ptr.To(false),
+		}).
 		Complete(r)
 }
 
+// indexReceivers registers the field indexes used to map Service, Namespace and WebhookTargetGrant events to Receivers or ClusterReceivers
+func indexReceivers(mgr ctrl.Manager, obj client.Object) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), obj, targetServiceIndex, indexTargetServices); err != nil {
+		return err
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), obj, serviceSelectorIndex, indexServiceSelector); err != nil {
+		return err
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), obj, namespaceSelectorIndex, indexNamespaceSelector); err != nil {
+		return err
+	}
+
+	return mgr.GetFieldIndexer().IndexField(context.Background(), obj, targetNamespaceIndex, indexTargetNamespaces)
+}
+
+func indexTargetServices(o client.Object) []string {
+	receiver, ok := o.(receiverObject)
+	if !ok {
+		panic(fmt.Sprintf("expected a Receiver or ClusterReceiver, got %T", o))
+	}
+
+	var names []string
+	for _, target := range receiver.GetSpec().Targets {
+		if target.Service.Name != "" && !slices.Contains(names, target.Service.Name) {
+			names = append(names, target.Service.Name)
+		}
+	}
+
+	return names
+}
+
+func indexServiceSelector(o client.Object) []string {
+	receiver, ok := o.(receiverObject)
+	if !ok {
+		panic(fmt.Sprintf("expected a Receiver or ClusterReceiver, got %T", o))
+	}
+
+	for _, target := range receiver.GetSpec().Targets {
+		if target.ServiceSelector != nil {
+			return []string{"true"}
+		}
+	}
+
+	return nil
+}
+
+func indexNamespaceSelector(o client.Object) []string {
+	receiver, ok := o.(receiverObject)
+	if !ok {
+		panic(fmt.Sprintf("expected a Receiver or ClusterReceiver, got %T", o))
+	}
+
+	for _, target := range receiver.GetSpec().Targets {
+		if target.NamespaceSelector != nil {
+			return []string{"true"}
+		}
+	}
+
+	return nil
+}
+
+func indexTargetNamespaces(o client.Object) []string {
+	receiver, ok := o.(receiverObject)
+	if !ok {
+		panic(fmt.Sprintf("expected a Receiver or ClusterReceiver, got %T", o))
+	}
+
+	var namespaces []string
+	for _, target := range receiver.GetSpec().Targets {
+		if target.Namespace != "" && !slices.Contains(namespaces, target.Namespace) {
+			namespaces = append(namespaces, target.Namespace)
+		}
+	}
+
+	return namespaces
+}
+
+var webhookPathChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldReceiver, ok := e.ObjectOld.(receiverObject)
+		if !ok {
+			return false
+		}
+
+		newReceiver, ok := e.ObjectNew.(receiverObject)
+		if !ok {
+			return false
+		}
+
+		return oldReceiver.GetStatus().WebhookPath != newReceiver.GetStatus().WebhookPath
+	},
+}
+
+// namespaceHandler enqueues the Receivers selecting a namespace which has been created, deleted or relabeled
+func (r *ReceiverReconciler) namespaceHandler() handler.EventHandler {
+	return handler.Funcs{
+		CreateFunc: func(ctx context.Context, e event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			r.enqueue(q, r.requestsForNamespaceLabels(ctx, e.Object.GetLabels()))
+		},
+		UpdateFunc: func(ctx context.Context, e event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			// Receivers selecting the namespace by either its old or new labels are affected
+			r.enqueue(q, r.requestsForNamespaceLabels(ctx, e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels()))
+		},
+		DeleteFunc: func(ctx context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			r.enqueue(q, r.requestsForNamespaceLabels(ctx, e.Object.GetLabels()))
+		},
+	}
+}
+
+func (r *ReceiverReconciler) enqueue(q workqueue.TypedRateLimitingInterface[reconcile.Request], reqs []reconcile.Request) {
+	for _, req := range reqs {
+		q.Add(req)
+	}
+}
+
+// listReceivers lists the Receivers or ClusterReceivers managed by the reconciler
+func (r *ReceiverReconciler) listReceivers(ctx context.Context, opts ...client.ListOption) ([]receiverObject, error) {
+	var list client.ObjectList = &infrav1.ReceiverList{}
+	if r.newList != nil {
+		list = r.newList()
+	}
+
+	if err := r.List(ctx, list, opts...); err != nil {
+		return nil, err
+	}
+
+	items, err := apimeta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+
+	receivers := make([]receiverObject, 0, len(items))
+	for _, item := range items {
+		receiver, ok := item.(receiverObject)
+		if !ok {
+			return nil, fmt.Errorf("expected a Receiver or ClusterReceiver, got %T", item)
+		}
+
+		receivers = append(receivers, receiver)
+	}
+
+	return receivers, nil
+}
+
+// requestsForNamespaceLabels returns the Receivers with a target namespace selector matching any of the given namespace labels
+func (r *ReceiverReconciler) requestsForNamespaceLabels(ctx context.Context, namespaceLabels ...map[string]string) []reconcile.Request {
+	receivers, err := r.listReceivers(ctx, client.MatchingFields{namespaceSelectorIndex: "true"})
+	if err != nil {
+		r.Log.Error(err, "failed to list Receivers selecting namespaces")
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for _, receiver := range receivers {
+		if r.selectsNamespace(receiver, namespaceLabels...) {
+			r.Log.V(1).Info("namespace labels of a Receiver target changed", "namespace", receiver.GetNamespace(), "receiver-name", receiver.GetName())
+			reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(receiver)})
+		}
+	}
+
+	return reqs
+}
+
+func (r *ReceiverReconciler) selectsNamespace(receiver receiverObject, namespaceLabels ...map[string]string) bool {
+	for _, target := range receiver.GetSpec().Targets {
+		if target.NamespaceSelector == nil {
+			continue
+		}
+
+		labelSel, err := metav1.LabelSelectorAsSelector(target.NamespaceSelector)
+		if err != nil {
+			r.Log.Error(err, "can not select resourceSelector selectors")
+			continue
+		}
+
+		for _, l := range namespaceLabels {
+			if labelSel.Matches(labels.Set(l)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// requestsForGrant returns the Receivers from the namespaces listed in the grant which target the namespace of the grant
+// either by name or by selecting target namespaces
+func (r *ReceiverReconciler) requestsForGrant(ctx context.Context, o client.Object) []reconcile.Request {
+	grant, ok := o.(*infrav1.WebhookTargetGrant)
+	if !ok {
+		panic(fmt.Sprintf("expected a WebhookTargetGrant, got %T", o))
+	}
+
+	bySelector, err := r.listReceivers(ctx, client.MatchingFields{namespaceSelectorIndex: "true"})
+	if err != nil {
+		r.Log.Error(err, "failed to list Receivers selecting namespaces")
+		return nil
+	}
+
+	byNamespace, err := r.listReceivers(ctx, client.MatchingFields{targetNamespaceIndex: grant.Namespace})
+	if err != nil {
+		r.Log.Error(err, "failed to list Receivers targeting the namespace", "namespace", grant.Namespace)
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for _, receiver := range append(bySelector, byNamespace...) {
+		req := reconcile.Request{NamespacedName: objectKey(receiver)}
+		if slices.Contains(reqs, req) {
+			continue
+		}
+
+		if slices.ContainsFunc(grant.Spec.From, func(from infrav1.WebhookTargetGrantFrom) bool {
+			return from.Namespace == receiver.GetNamespace()
+		}) {
+			r.Log.V(1).Info("WebhookTargetGrant of a Receiver target changed", "namespace", receiver.GetNamespace(), "receiver-name", receiver.GetName())
+			reqs = append(reqs, req)
+		}
+	}
+
+	return reqs
+}
+
 func (r *ReceiverReconciler) requestsForChangeBySelector(ctx context.Context, o client.Object) []reconcile.Request {
 	svc, ok := o.(*v1.Service)
 	if !ok {
@@ -87,44 +388,67 @@ func (r *ReceiverReconciler) requestsForChangeBySelector(ctx context.Context, o
 		}
 	}
 
-	var list v1beta1.ReceiverList
-	if err := r.List(ctx, &list); err != nil {
+	byName, err := r.listReceivers(ctx, client.MatchingFields{targetServiceIndex: svc.Name})
+	if err != nil {
+		return nil
+	}
+
+	bySelector, err := r.listReceivers(ctx, client.MatchingFields{serviceSelectorIndex: "true"})
+	if err != nil {
 		return nil
 	}
 
 	var reqs []reconcile.Request
-	for _, receiver := range list.Items {
-		receiver := receiver
-		if receiver.Spec.Targets == nil {
+	for _, receiver := range append(byName, bySelector...) {
+		req := reconcile.Request{NamespacedName: objectKey(receiver)}
+		if slices.Contains(reqs, req) {
 			continue
 		}
 
-		for _, target := range receiver.Spec.Targets {
-			if target.Service.Name != svc.Name {
-				continue
+		for _, target := range receiver.GetSpec().Targets {
+			if r.targetsService(receiver, target, svc, &ns) {
+				r.Log.V(1).Info("referenced resource from a Receiver changed detected", "namespace", receiver.GetNamespace(), "receiver-name", receiver.GetName())
+				reqs = append(reqs, req)
+				break
 			}
+		}
+	}
 
-			if target.NamespaceSelector == nil {
-				if receiver.Namespace == svc.Namespace {
-					r.Log.V(1).Info("referenced resource from a Receiver changed detected", "namespace", receiver.Namespace, "receiver-name", receiver.Name)
-					reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(&receiver)})
-				}
-			} else {
-				labelSel, err := metav1.LabelSelectorAsSelector(target.NamespaceSelector)
-				if err != nil {
-					r.Log.Error(err, "can not select resourceSelector selectors")
-					continue
-				}
+	return reqs
+}
 
-				if labelSel.Matches(labels.Set(ns.GetLabels())) {
-					r.Log.V(1).Info("referenced resource from a Receiver changed detected", "namespace", receiver.Namespace, "receiver-name", receiver.Name)
-					reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(&receiver)})
-				}
-			}
+// targetsService reports whether the service in the given namespace is selected by the target
+func (r *ReceiverReconciler) targetsService(receiver receiverObject, target infrav1.Target, svc *v1.Service, ns *v1.Namespace) bool {
+	if target.ServiceSelector == nil {
+		if target.Service.Name != svc.Name {
+			return false
+		}
+	} else {
+		serviceSelector, err := metav1.LabelSelectorAsSelector(target.ServiceSelector)
+		if err != nil {
+			r.Log.Error(err, "can not select serviceSelector selectors")
+			return false
+		}
+
+		if !serviceSelector.Matches(labels.Set(svc.GetLabels())) {
+			return false
 		}
 	}
 
-	return reqs
+	switch {
+	case target.NamespaceSelector != nil:
+		labelSel, err := metav1.LabelSelectorAsSelector(target.NamespaceSelector)
+		if err != nil {
+			r.Log.Error(err, "can not select resourceSelector selectors")
+			return false
+		}
+
+		return labelSel.Matches(labels.Set(ns.GetLabels()))
+	case target.Namespace != "":
+		return target.Namespace == svc.Namespace
+	default:
+		return receiver.GetNamespace() == svc.Namespace
+	}
 }
 
 var chars = []rune("abcdefghijklmnopqrstuvwxyz123456789")
@@ -143,29 +467,42 @@ func (r *ReceiverReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	logger.Info("reconciling Receiver")
 
 	// Fetch the Receiver instance
-	receiver := v1beta1.Receiver{}
+	receiver := infrav1.Receiver{}
 
 	err := r.Get(ctx, req.NamespacedName, &receiver)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
-			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
-			// Return and don't requeue
-			return reconcile.Result{}, nil
+			// Remove its registration from the proxy and don't requeue
+			return reconcile.Result{}, r.HttpProxy.UnregisterOwner(req.Namespace, req.Name)
 		}
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
 
-	if receiver.Spec.Suspend {
+	return r.reconcileReceiver(ctx, &receiver, logger)
+}
+
+// reconcileReceiver registers a Receiver or ClusterReceiver in the proxy, only the leader updates the status
+func (r *ReceiverReconciler) reconcileReceiver(ctx context.Context, receiver receiverObject, logger logr.Logger) (ctrl.Result, error) {
+	if receiver.GetSpec().Suspend {
 		return ctrl.Result{}, nil
 	}
 
-	receiver.Status.ObservedGeneration = receiver.Generation
-	receiver, result, reconcileErr := r.reconcile(ctx, receiver, logger)
+	// Replicas which are not the leader register the receiver using the webhook path assigned by the leader
+	if !r.isLeader() {
+		if receiver.GetStatus().WebhookPath == "" {
+			return ctrl.Result{RequeueAfter: followerRequeueInterval}, nil
+		}
+
+		return r.reconcile(ctx, receiver, logger)
+	}
+
+	receiver.GetStatus().ObservedGeneration = receiver.GetGeneration()
+	result, reconcileErr := r.reconcile(ctx, receiver, logger)
 
 	// Update status after reconciliation.
-	if err = r.patchStatus(ctx, &receiver); err != nil {
+	if err := r.patchStatus(ctx, receiver); err != nil {
 		logger.Error(err, "unable to update status after reconciliation")
 		return ctrl.Result{Requeue: true}, err
 	}
@@ -173,129 +510,424 @@ func (r *ReceiverReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	return result, reconcileErr
 }
 
-func (r *ReceiverReconciler) reconcile(ctx context.Context, receiver v1beta1.Receiver, logger logr.Logger) (v1beta1.Receiver, ctrl.Result, error) {
-	receiver, services, err := r.extendWithTargets(ctx, receiver, logger)
+func (r *ReceiverReconciler) reconcile(ctx context.Context, receiver receiverObject, logger logr.Logger) (ctrl.Result, error) {
+	spec := receiver.GetSpec()
+	status := receiver.GetStatus()
+
+	for i, target := range spec.Targets {
+		if err := validateTarget(target, receiver.GetNamespace() == ""); err != nil {
+			if err := r.HttpProxy.Unregister(status.WebhookPath); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			msg := fmt.Sprintf("invalid target %d: %s", i, err)
+			r.event(receiver, msg)
+			setCondition(receiver, infrav1.ConditionReady, metav1.ConditionFalse, infrav1.InvalidTargetReason, msg)
+			return ctrl.Result{}, nil
+		}
+	}
+
+	services, err := r.extendWithTargets(ctx, receiver, logger)
 	if err != nil {
-		return receiver, ctrl.Result{}, err
+		return ctrl.Result{}, err
 	}
 
-	if receiver.Status.WebhookPath == "" {
-		receiver.Status.WebhookPath = fmt.Sprintf("/hooks/%s", randSeq(32))
+	if status.WebhookPath == "" {
+		status.WebhookPath = fmt.Sprintf("/hooks/%s", randSeq(32))
 	}
 
 	var targets []proxy.Target
 
 	for _, svc := range services {
-		targets = append(targets, proxy.Target{
+		target := proxy.Target{
 			Address:          svc.addr,
-			ResponseType:     proxy.ResponseType(receiver.Spec.ResponseType),
 			Port:             svc.port,
 			ServiceName:      svc.ref.Name,
 			ServiceNamespace: svc.ref.Namespace,
 			Path:             svc.path,
-		})
+			Primary:          svc.target.Name != "" && svc.target.Name == spec.Response.PrimaryTarget,
+			BodySizeLimit:    svc.target.BodySizeLimit,
+			FireAndForget:    svc.target.FireAndForget,
+		}
+
+		if svc.target.Timeout != nil {
+			target.Timeout = svc.target.Timeout.Duration
+		}
+
+		if policy := svc.target.CircuitBreaker; policy != nil {
+			target.CircuitBreaker = &proxy.CircuitBreakerPolicy{
+				ConsecutiveFailures: int(policy.ConsecutiveFailures),
+				FailurePercentage:   int(policy.FailurePercentage),
+				MinimumDeliveries:   int(policy.MinimumDeliveries),
+				Window:              policy.Window.Duration,
+				OpenDuration:        policy.OpenDuration.Duration,
+				HalfOpenProbes:      int(policy.HalfOpenProbes),
+				OpenAction:          proxy.CircuitOpenAction(policy.OpenAction),
+				QueueTimeout:        policy.QueueTimeout.Duration,
+				QueueLimit:          int(policy.QueueLimit),
+			}
+		}
+
+		if svc.target.Delay != nil {
+			target.Delay = svc.target.Delay.Duration
+		}
+
+		if policy := svc.target.Debounce; policy != nil {
+			target.Debounce = &proxy.DebouncePolicy{
+				Window: policy.Window.Duration,
+				Keep:   proxy.DebounceKeep(policy.Keep),
+			}
+
+			if policy.Key != nil {
+				target.Debounce.Key = proxy.RequestKey(*policy.Key)
+			}
+		}
+
+		if policy := svc.target.Sampling; policy != nil {
+			target.Sampling = &proxy.SamplingPolicy{
+				Percentage: int(policy.Percentage),
+				Key:        (*proxy.RequestKey)(policy.Key),
+			}
+		}
+
+		if err := withSuccessCriteria(&target, svc.target); err != nil {
+			if err := r.HttpProxy.Unregister(status.WebhookPath); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			msg := fmt.Sprintf("invalid target %s/%s: %s", svc.ref.Namespace, svc.ref.Name, err)
+			r.event(receiver, msg)
+			setCondition(receiver, infrav1.ConditionReady, metav1.ConditionFalse, infrav1.InvalidTargetReason, msg)
+			return ctrl.Result{}, nil
+		}
+
+		targets = append(targets, target)
 	}
 
 	if len(targets) == 0 {
-		if err := r.HttpProxy.Unregister(receiver.Status.WebhookPath); err != nil {
-			return receiver, ctrl.Result{}, err
+		if err := r.HttpProxy.Unregister(status.WebhookPath); err != nil {
+			return ctrl.Result{}, err
 		}
 
 		msg := "no targets found"
-		r.Recorder.Event(&receiver, "Normal", "info", msg)
-		return v1beta1.ReceiverNotReady(receiver, v1beta1.ServiceBackendReadyReason, msg), ctrl.Result{}, nil
+		r.event(receiver, msg)
+		setCondition(receiver, infrav1.ConditionReady, metav1.ConditionFalse, infrav1.ServiceBackendReadyReason, msg)
+		return ctrl.Result{}, nil
+	}
+
+	if spec.Response.Type == infrav1.Primary && !slices.ContainsFunc(targets, func(t proxy.Target) bool { return t.Primary }) {
+		if err := r.HttpProxy.Unregister(status.WebhookPath); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		msg := fmt.Sprintf("primary target %q not found", spec.Response.PrimaryTarget)
+		r.event(receiver, msg)
+		setCondition(receiver, infrav1.ConditionReady, metav1.ConditionFalse, infrav1.ServiceBackendReadyReason, msg)
+		return ctrl.Result{}, nil
+	}
+
+	gvk, err := apiutil.GVKForObject(receiver, r.Scheme())
+	if err != nil {
+		return ctrl.Result{}, err
 	}
 
-	err = r.HttpProxy.RegisterOrUpdate(proxy.Receiver{
-		Timeout:       receiver.Spec.Timeout.Duration,
-		Path:          receiver.Status.WebhookPath,
+	proxyReceiver := proxy.Receiver{
+		Timeout:       spec.Timeout.Duration,
+		Path:          status.WebhookPath,
 		Targets:       targets,
-		ResponseType:  proxy.ResponseType(receiver.Spec.ResponseType),
-		BodySizeLimit: receiver.Spec.BodySizeLimit,
+		ResponseType:  proxy.ResponseType(spec.Response.Type),
+		BodySizeLimit: spec.BodySizeLimit,
+		Quorum:        int(spec.Response.Quorum),
+
+		BodySizeLimitAction: proxy.BodySizeLimitAction(spec.BodySizeLimitAction),
+		Object: &v1.ObjectReference{
+			Kind:            gvk.Kind,
+			APIVersion:      gvk.GroupVersion().String(),
+			Namespace:       receiver.GetNamespace(),
+			Name:            receiver.GetName(),
+			UID:             receiver.GetUID(),
+			ResourceVersion: receiver.GetResourceVersion(),
+		},
+		Generation: receiver.GetGeneration(),
+	}
+
+	if spec.Streaming != nil {
+		proxyReceiver.Streaming = true
+		proxyReceiver.StreamBufferSize = spec.Streaming.BufferSize
+	}
+
+	if spec.OrderingKey != nil {
+		proxyReceiver.OrderingKey = (*proxy.RequestKey)(spec.OrderingKey)
+	}
+
+	if spec.Response.Report != nil {
+		proxyReceiver.Report = proxy.ReportOptions{
+			StatusCode:    proxy.ReportStatusCode(spec.Response.Report.StatusCode),
+			BodySizeLimit: spec.Response.Report.BodySizeLimit,
+		}
+	}
+
+	if err := r.HttpProxy.RegisterOrUpdate(proxyReceiver); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	msg := "receiver successfully registered"
+	r.event(receiver, msg)
+	setCondition(receiver, infrav1.ConditionReady, metav1.ConditionTrue, infrav1.ServiceBackendReadyReason, msg)
+	return ctrl.Result{}, nil
+}
+
+// setCondition sets a condition on the status of a Receiver or ClusterReceiver
+func setCondition(receiver receiverObject, condition string, status metav1.ConditionStatus, reason, message string) {
+	apimeta.SetStatusCondition(&receiver.GetStatus().Conditions, metav1.Condition{
+		Type:    condition,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
 	})
+}
 
+// isLeader reports whether this replica is the leader and is allowed to update the status
+func (r *ReceiverReconciler) isLeader() bool {
+	if r.elected == nil {
+		return true
+	}
+
+	select {
+	case <-r.elected:
+		return true
+	default:
+		return false
+	}
+}
+
+// event records an event for the receiver, replicas which are not the leader do not record events
+func (r *ReceiverReconciler) event(receiver client.Object, msg string) {
+	if r.isLeader() {
+		r.Recorder.Event(receiver, "Normal", "info", msg)
+	}
+}
+
+// ReadyzCheck passes once the Receiver cache has synced and every Ready Receiver is registered in the proxy
+func (r *ReceiverReconciler) ReadyzCheck(req *http.Request) error {
+	ctx, cancel := context.WithTimeout(req.Context(), cacheSyncTimeout)
+	defer cancel()
+
+	if r.cache == nil || !r.cache.WaitForCacheSync(ctx) {
+		return fmt.Errorf("receiver cache not synced")
+	}
+
+	receivers, err := r.listReceivers(ctx)
 	if err != nil {
-		return v1beta1.Receiver{}, ctrl.Result{}, err
+		return err
 	}
 
-	msg := "receiver successfully registered"
-	r.Recorder.Event(&receiver, "Normal", "info", msg)
-	return v1beta1.ReceiverReady(receiver, v1beta1.ServiceBackendReadyReason, msg), ctrl.Result{}, err
+	for _, receiver := range receivers {
+		status := receiver.GetStatus()
+		if receiver.GetSpec().Suspend || status.WebhookPath == "" {
+			continue
+		}
+
+		if !apimeta.IsStatusConditionTrue(status.Conditions, infrav1.ConditionReady) {
+			continue
+		}
+
+		if !r.HttpProxy.IsRegistered(status.WebhookPath) {
+			return fmt.Errorf("receiver %s not registered yet", objectKey(receiver))
+		}
+	}
+
+	return nil
+}
+
+// validateTarget checks that a target selects its services either by name or by labels.
+// Targets of cluster scoped receivers must select a namespace.
+func validateTarget(target infrav1.Target, clusterScoped bool) error {
+	switch {
+	case target.Service.Name == "" && target.ServiceSelector == nil:
+		return fmt.Errorf("either service.name or serviceSelector is required")
+	case target.Service.Name != "" && target.ServiceSelector != nil:
+		return fmt.Errorf("service.name and serviceSelector are mutually exclusive")
+	case target.Namespace != "" && target.NamespaceSelector != nil:
+		return fmt.Errorf("namespace and namespaceSelector are mutually exclusive")
+	case clusterScoped && target.Namespace == "" && target.NamespaceSelector == nil:
+		return fmt.Errorf("either namespace or namespaceSelector is required")
+	}
+
+	return nil
+}
+
+// withSuccessCriteria configures how responses of a target are classified and retried
+func withSuccessCriteria(target *proxy.Target, spec infrav1.Target) error {
+	for _, code := range spec.SuccessCodes {
+		r, err := proxy.ParseStatusCodeRange(string(code))
+		if err != nil {
+			return err
+		}
+
+		target.SuccessCodes = append(target.SuccessCodes, r)
+	}
+
+	for _, code := range spec.FailureCodes {
+		r, err := proxy.ParseStatusCodeRange(string(code))
+		if err != nil {
+			return err
+		}
+
+		target.FailureCodes = append(target.FailureCodes, r)
+	}
+
+	if spec.SuccessExpression != "" {
+		expr, err := proxy.CompileExpression(spec.SuccessExpression)
+		if err != nil {
+			return err
+		}
+
+		target.SuccessExpression = expr
+	}
+
+	if spec.Retry != nil {
+		target.Retry = proxy.RetryPolicy{
+			Attempts: int(spec.Retry.Attempts),
+			Interval: spec.Retry.Interval.Duration,
+		}
+	}
+
+	return nil
 }
 
 type targetService struct {
-	addr string
-	port int32
-	path string
-	ref  v1beta1.ResourceReference
+	target infrav1.Target
+	addr   string
+	port   int32
+	path   string
+	ref    infrav1.ResourceReference
 }
 
-func (r *ReceiverReconciler) extendWithTargets(ctx context.Context, receiver v1beta1.Receiver, logger logr.Logger) (v1beta1.Receiver, []targetService, error) {
+func (r *ReceiverReconciler) extendWithTargets(ctx context.Context, receiver receiverObject, logger logr.Logger) ([]targetService, error) {
 	var services []targetService
 
-	receiver.Status.SubResourceCatalog = []v1beta1.ResourceReference{}
+	status := receiver.GetStatus()
+	status.Services = []infrav1.ResourceReference{}
+	status.Targets = []infrav1.TargetStatus{}
 
-	for _, target := range receiver.Spec.Targets {
+	for _, target := range receiver.GetSpec().Targets {
 		var namespaces v1.NamespaceList
 		if target.NamespaceSelector == nil {
 			namespaces.Items = append(namespaces.Items, v1.Namespace{
 				ObjectMeta: metav1.ObjectMeta{
-					Name: receiver.Namespace,
+					Name: cmp.Or(target.Namespace, receiver.GetNamespace()),
 				},
 			})
 		} else {
 			namespaceSelector, err := metav1.LabelSelectorAsSelector(target.NamespaceSelector)
 			if err != nil {
-				return receiver, nil, err
+				return nil, err
 			}
 
 			err = r.List(ctx, &namespaces, client.MatchingLabelsSelector{Selector: namespaceSelector})
 			if err != nil {
-				return receiver, nil, err
+				return nil, err
 			}
 		}
 
-		for _, namespace := range namespaces.Items {
-			service := v1.Service{}
-			err := r.Get(ctx, client.ObjectKey{
-				Namespace: namespace.Name,
-				Name:      target.Service.Name,
-			}, &service)
+		if len(namespaces.Items) == 0 {
+			status.Targets = append(status.Targets, infrav1.TargetStatus{
+				Name:    target.Name,
+				Service: target.Service.Name,
+				Reason:  infrav1.NamespaceNotSelectedReason,
+				Message: "namespace selector does not match any namespace",
+			})
+			continue
+		}
 
+		for _, namespace := range namespaces.Items {
+			matches, err := r.targetServices(ctx, target, namespace.Name)
 			if err != nil {
-				logger.V(1).Error(err, "no service found for target", "namespace", namespace.Name, "service", target.Service.Name)
-				continue
+				return nil, err
 			}
 
-			var port int32
-			for _, p := range service.Spec.Ports {
-				if target.Service.Port.Name != nil && p.Name == *target.Service.Port.Name {
-					port = p.Port
-				} else if target.Service.Port.Number != nil && p.Port == *target.Service.Port.Number {
-					port = p.Port
+			if len(matches) == 0 {
+				logger.V(1).Info("no service found for target", "namespace", namespace.Name, "service", target.Service.Name)
+				targetStatus := infrav1.TargetStatus{
+					Name:      target.Name,
+					Service:   target.Service.Name,
+					Namespace: namespace.Name,
+					Reason:    infrav1.ServiceNotFoundReason,
+					Message:   "service not found",
 				}
-			}
 
-			if port == 0 {
-				logger.V(1).Error(err, "port not found for target", "namespace", namespace.Name, "service", target.Service.Name)
-				continue
-			}
+				if target.ServiceSelector != nil {
+					targetStatus.Message = "no service matches the service selector"
+				}
 
-			if service.Spec.ClusterIP == "" {
+				status.Targets = append(status.Targets, targetStatus)
 				continue
 			}
 
-			services = append(services, targetService{
-				addr: service.Spec.ClusterIP,
-				path: target.Path,
-				port: port,
-				ref: v1beta1.ResourceReference{
-					Kind:       service.Kind,
-					Name:       service.Name,
-					Namespace:  service.Namespace,
-					APIVersion: service.APIVersion,
-				},
-			})
+			for _, service := range matches {
+				targetStatus := infrav1.TargetStatus{
+					Name:      target.Name,
+					Service:   service.Name,
+					Namespace: namespace.Name,
+				}
+
+				permitted, err := targetPermitted(ctx, r, r.AllowCrossNamespaceTargets, receiver.GetNamespace(), service)
+				if err != nil {
+					return nil, err
+				}
+
+				if !permitted {
+					logger.V(1).Info("target not permitted", "namespace", namespace.Name, "service", service.Name)
+					targetStatus.Reason = infrav1.TargetNotPermittedReason
+					targetStatus.Message = fmt.Sprintf("no WebhookTargetGrant in namespace %s permits Receivers from namespace %s", service.Namespace, receiver.GetNamespace())
+					status.Targets = append(status.Targets, targetStatus)
+					continue
+				}
+
+				var port int32
+				for _, p := range service.Spec.Ports {
+					if target.Service.Port.Name != "" && p.Name == target.Service.Port.Name {
+						port = p.Port
+					} else if target.Service.Port.Number != 0 && p.Port == target.Service.Port.Number {
+						port = p.Port
+					}
+				}
+
+				if port == 0 {
+					logger.V(1).Info("port not found for target", "namespace", namespace.Name, "service", service.Name)
+					targetStatus.Reason = infrav1.PortNotFoundReason
+					targetStatus.Message = fmt.Sprintf("port %s not found", servicePortString(target.Service.Port))
+					status.Targets = append(status.Targets, targetStatus)
+					continue
+				}
+
+				targetStatus.Port = port
+
+				if service.Spec.ClusterIP == "" || service.Spec.ClusterIP == v1.ClusterIPNone {
+					targetStatus.Reason = infrav1.HeadlessServiceReason
+					targetStatus.Message = "service has no cluster ip"
+					status.Targets = append(status.Targets, targetStatus)
+					continue
+				}
+
+				targetStatus.Resolved = true
+				targetStatus.Reason = infrav1.TargetResolvedReason
+				status.Targets = append(status.Targets, targetStatus)
+
+				services = append(services, targetService{
+					target: target,
+					addr:   service.Spec.ClusterIP,
+					path:   target.Path,
+					port:   port,
+					ref: infrav1.ResourceReference{
+						Kind:       service.Kind,
+						Name:       service.Name,
+						Namespace:  service.Namespace,
+						APIVersion: service.APIVersion,
+					},
+				})
+			}
 		}
 	}
 
@@ -307,19 +939,121 @@ func (r *ReceiverReconciler) extendWithTargets(ctx context.Context, receiver v1b
 	})
 
 	for _, svc := range services {
-		receiver.Status.SubResourceCatalog = append(receiver.Status.SubResourceCatalog, svc.ref)
+		status.Services = append(status.Services, svc.ref)
+	}
+
+	setTargetsResolved(receiver)
+	return services, nil
+}
+
+// targetServices returns the services of a target in the given namespace, either the service by name or all services matching the service selector
+func (r *ReceiverReconciler) targetServices(ctx context.Context, target infrav1.Target, namespace string) ([]v1.Service, error) {
+	if target.ServiceSelector != nil {
+		serviceSelector, err := metav1.LabelSelectorAsSelector(target.ServiceSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		var list v1.ServiceList
+		if err := r.List(ctx, &list, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: serviceSelector}); err != nil {
+			return nil, err
+		}
+
+		slices.SortFunc(list.Items, func(a, b v1.Service) int {
+			return cmp.Compare(a.Name, b.Name)
+		})
+
+		return list.Items, nil
+	}
+
+	service := v1.Service{}
+	err := r.Get(ctx, client.ObjectKey{
+		Namespace: namespace,
+		Name:      target.Service.Name,
+	}, &service)
+
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return []v1.Service{service}, nil
+}
+
+// targetPermitted reports whether Receivers from the given namespace may forward webhooks to the service.
+// Services in other namespaces require a WebhookTargetGrant in the namespace of the service.
+// ClusterReceivers may target any namespace.
+func targetPermitted(ctx context.Context, c client.Reader, allowCrossNamespace bool, namespace string, service v1.Service) (bool, error) {
+	if allowCrossNamespace || namespace == "" || service.Namespace == namespace {
+		return true, nil
+	}
+
+	var grants infrav1.WebhookTargetGrantList
+	if err := c.List(ctx, &grants, client.InNamespace(service.Namespace)); err != nil {
+		return false, err
+	}
+
+	return slices.ContainsFunc(grants.Items, func(grant infrav1.WebhookTargetGrant) bool {
+		return grant.Permits(namespace, service.Name)
+	}), nil
+}
+
+// setTargetsResolved sets the TargetsResolved condition from the resolution state of the targets
+func setTargetsResolved(receiver receiverObject) {
+	var resolved int
+	targets := receiver.GetStatus().Targets
+	for _, target := range targets {
+		if target.Resolved {
+			resolved++
+		}
 	}
 
-	return receiver, services, nil
+	total := len(targets)
+
+	switch {
+	case resolved == 0:
+		setCondition(receiver, infrav1.ConditionTargetsResolved, metav1.ConditionFalse, infrav1.NoTargetsResolvedReason, fmt.Sprintf("0 of %d targets resolved", total))
+	case resolved == total:
+		setCondition(receiver, infrav1.ConditionTargetsResolved, metav1.ConditionTrue, infrav1.AllTargetsResolvedReason, fmt.Sprintf("%d of %d targets resolved", resolved, total))
+	default:
+		setCondition(receiver, infrav1.ConditionTargetsResolved, metav1.ConditionFalse, infrav1.TargetsPartiallyResolvedReason, fmt.Sprintf("%d of %d targets resolved", resolved, total))
+	}
+}
+
+// servicePortString returns the name or number of a service port
+func servicePortString(port infrav1.ServicePort) string {
+	switch {
+	case port.Name != "":
+		return port.Name
+	case port.Number != 0:
+		return strconv.Itoa(int(port.Number))
+	default:
+		return "<unset>"
+	}
 }
 
-func (r *ReceiverReconciler) patchStatus(ctx context.Context, receiver *v1beta1.Receiver) error {
+func (r *ReceiverReconciler) patchStatus(ctx context.Context, receiver receiverObject) error {
 	key := client.ObjectKeyFromObject(receiver)
-	latest := &v1beta1.Receiver{}
+	latest, ok := receiver.DeepCopyObject().(receiverObject)
+	if !ok {
+		return fmt.Errorf("expected a Receiver or ClusterReceiver, got %T", receiver)
+	}
+
 	if err := r.Get(ctx, key, latest); err != nil {
 		return err
 	}
 
+	// The delivery statistics and the Degraded condition are owned by the DeliveryStatusUpdater
+	status := receiver.GetStatus()
+	status.Deliveries = latest.GetStatus().Deliveries
+	apimeta.RemoveStatusCondition(&status.Conditions, infrav1.ConditionDegraded)
+	if degraded := apimeta.FindStatusCondition(latest.GetStatus().Conditions, infrav1.ConditionDegraded); degraded != nil {
+		status.Conditions = append(status.Conditions, *degraded)
+	}
+
 	return r.Status().Patch(ctx, receiver, client.MergeFrom(latest))
 }
 
@@ -3,15 +3,23 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"testing"
 	"time"
 
-	"github.com/DoodleScheduling/webhook-controller/api/v1beta1"
+	infrav1 "github.com/DoodleScheduling/webhook-controller/api/v1"
+	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 var _ = Describe("Receiver controller", func() {
@@ -20,7 +28,7 @@ var _ = Describe("Receiver controller", func() {
 		interval = time.Millisecond * 200
 	)
 
-	var eventuallyMatchExactConditions = func(ctx context.Context, instanceLookupKey types.NamespacedName, reconciledInstance *v1beta1.Receiver, expectedStatus *v1beta1.ReceiverStatus) {
+	var eventuallyMatchExactConditions = func(ctx context.Context, instanceLookupKey types.NamespacedName, reconciledInstance *infrav1.Receiver, expectedStatus *infrav1.ReceiverStatus) {
 		Eventually(func() error {
 			err := k8sClient.Get(ctx, instanceLookupKey, reconciledInstance)
 			if err != nil {
@@ -38,92 +46,74 @@ var _ = Describe("Receiver controller", func() {
 			By("creating a new Receiver")
 			ctx := context.Background()
 
-			receiver := &v1beta1.Receiver{
+			receiver := &infrav1.Receiver{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      receiverName,
 					Namespace: "default",
 				},
-				Spec: v1beta1.ReceiverSpec{
+				Spec: infrav1.ReceiverSpec{
 					Suspend: true,
-					Targets: []v1beta1.Target{},
+					Targets: []infrav1.Target{
+						{
+							Service: infrav1.ServiceReference{
+								Name: "podinfo",
+								Port: infrav1.ServicePort{
+									Name: "http",
+								},
+							},
+						},
+					},
 				},
 			}
 			Expect(k8sClient.Create(ctx, receiver)).Should(Succeed())
 
 			By("waiting for the reconciliation")
 			instanceLookupKey := types.NamespacedName{Name: receiverName, Namespace: "default"}
-			reconciledInstance := &v1beta1.Receiver{}
+			reconciledInstance := &infrav1.Receiver{}
 
-			eventuallyMatchExactConditions(ctx, instanceLookupKey, reconciledInstance, &v1beta1.ReceiverStatus{})
+			eventuallyMatchExactConditions(ctx, instanceLookupKey, reconciledInstance, &infrav1.ReceiverStatus{})
 		})
 	})
 
-	When("it reconciles a receiver without targets", func() {
-		receiverName := fmt.Sprintf("receiver-%s", randStringRunes(5))
-		var receiver *v1beta1.Receiver
-
-		It("creates a new receiver", func() {
+	When("it creates a receiver without targets", func() {
+		It("is rejected by the validation rules", func() {
 			ctx := context.Background()
 
-			receiver = &v1beta1.Receiver{
+			receiver := &infrav1.Receiver{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:      receiverName,
+					Name:      fmt.Sprintf("receiver-%s", randStringRunes(5)),
 					Namespace: "default",
 				},
-				Spec: v1beta1.ReceiverSpec{
-					Targets: []v1beta1.Target{},
-				},
-			}
-			Expect(k8sClient.Create(ctx, receiver)).Should(Succeed())
-		})
-
-		It("should update the receiver status", func() {
-			ctx := context.Background()
-			reconciledInstance := &v1beta1.Receiver{}
-			instanceLookupKey := types.NamespacedName{Name: receiverName, Namespace: "default"}
-
-			expectedStatus := &v1beta1.ReceiverStatus{
-				ObservedGeneration: 1,
-				Conditions: []metav1.Condition{
-					{
-						Type:    v1beta1.ConditionReady,
-						Status:  metav1.ConditionFalse,
-						Reason:  "ServiceBackendReady",
-						Message: "no targets found",
-					},
+				Spec: infrav1.ReceiverSpec{
+					Targets: []infrav1.Target{},
 				},
 			}
-			eventuallyMatchExactConditions(ctx, instanceLookupKey, reconciledInstance, expectedStatus)
-		})
-
-		It("cleans up", func() {
-			ctx := context.Background()
-			Expect(k8sClient.Delete(ctx, receiver)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, receiver)).ShouldNot(Succeed())
 		})
 	})
 
 	When("it reconciles a Receiver with targets", func() {
 		svcName := fmt.Sprintf("svc-%s", randStringRunes(5))
 		receiverName := fmt.Sprintf("receiver-%s", randStringRunes(5))
-		var receiver *v1beta1.Receiver
+		var receiver *infrav1.Receiver
 		portName := "http"
 		var svc *v1.Service
 
 		It("creates a new Receiver", func() {
 			ctx := context.Background()
 
-			receiver = &v1beta1.Receiver{
+			receiver = &infrav1.Receiver{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      receiverName,
 					Namespace: "default",
 				},
-				Spec: v1beta1.ReceiverSpec{
-					Targets: []v1beta1.Target{
+				Spec: infrav1.ReceiverSpec{
+					Targets: []infrav1.Target{
 						{
-							Service: v1beta1.ServiceSelector{
+							Service: infrav1.ServiceReference{
 								Name: svcName,
-								Port: v1beta1.ServicePort{
-									Name: &portName,
+								Port: infrav1.ServicePort{
+									Name: portName,
 								},
 							},
 						},
@@ -135,18 +125,24 @@ var _ = Describe("Receiver controller", func() {
 
 		It("should update the Receiver status", func() {
 			ctx := context.Background()
-			reconciledInstance := &v1beta1.Receiver{}
+			reconciledInstance := &infrav1.Receiver{}
 			instanceLookupKey := types.NamespacedName{Name: receiverName, Namespace: "default"}
 
-			expectedStatus := &v1beta1.ReceiverStatus{
+			expectedStatus := &infrav1.ReceiverStatus{
 				ObservedGeneration: 1,
 				Conditions: []metav1.Condition{
 					{
-						Type:    v1beta1.ConditionReady,
+						Type:    infrav1.ConditionReady,
 						Status:  metav1.ConditionFalse,
 						Reason:  "ServiceBackendReady",
 						Message: "no targets found",
 					},
+					{
+						Type:    infrav1.ConditionTargetsResolved,
+						Status:  metav1.ConditionFalse,
+						Reason:  infrav1.NoTargetsResolvedReason,
+						Message: "0 of 1 targets resolved",
+					},
 				},
 			}
 			eventuallyMatchExactConditions(ctx, instanceLookupKey, reconciledInstance, expectedStatus)
@@ -174,18 +170,24 @@ var _ = Describe("Receiver controller", func() {
 
 		It("should update the Receiver status", func() {
 			ctx := context.Background()
-			reconciledInstance := &v1beta1.Receiver{}
+			reconciledInstance := &infrav1.Receiver{}
 			instanceLookupKey := types.NamespacedName{Name: receiverName, Namespace: "default"}
 
-			expectedStatus := &v1beta1.ReceiverStatus{
+			expectedStatus := &infrav1.ReceiverStatus{
 				ObservedGeneration: 1,
 				Conditions: []metav1.Condition{
 					{
-						Type:    v1beta1.ConditionReady,
+						Type:    infrav1.ConditionReady,
 						Status:  metav1.ConditionTrue,
 						Reason:  "ServiceBackendReady",
 						Message: "receiver successfully registered",
 					},
+					{
+						Type:    infrav1.ConditionTargetsResolved,
+						Status:  metav1.ConditionTrue,
+						Reason:  infrav1.AllTargetsResolvedReason,
+						Message: "1 of 1 targets resolved",
+					},
 				},
 			}
 			eventuallyMatchExactConditions(ctx, instanceLookupKey, reconciledInstance, expectedStatus)
@@ -197,4 +199,378 @@ var _ = Describe("Receiver controller", func() {
 			Expect(k8sClient.Delete(ctx, receiver)).Should(Succeed())
 		})
 	})
+
+	When("it reconciles a Receiver with a target in another namespace", func() {
+		namespaceName := fmt.Sprintf("team-%s", randStringRunes(5))
+		receiverName := fmt.Sprintf("receiver-%s", randStringRunes(5))
+		instanceLookupKey := types.NamespacedName{Name: receiverName, Namespace: "default"}
+		var receiver *infrav1.Receiver
+
+		eventuallyTargetReason := func(ctx context.Context, reason string) {
+			Eventually(func() string {
+				reconciledInstance := &infrav1.Receiver{}
+				if err := k8sClient.Get(ctx, instanceLookupKey, reconciledInstance); err != nil || len(reconciledInstance.Status.Targets) == 0 {
+					return ""
+				}
+
+				return reconciledInstance.Status.Targets[0].Reason
+			}, timeout, interval).Should(Equal(reason))
+		}
+
+		It("creates a new Receiver targeting a service in another namespace", func() {
+			ctx := context.Background()
+			Expect(k8sClient.Create(ctx, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}})).Should(Succeed())
+			Expect(k8sClient.Create(ctx, &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: namespaceName},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{{Name: "http", Port: 8080, TargetPort: intstr.FromInt(8080)}},
+				},
+			})).Should(Succeed())
+
+			receiver = &infrav1.Receiver{
+				ObjectMeta: metav1.ObjectMeta{Name: receiverName, Namespace: "default"},
+				Spec: infrav1.ReceiverSpec{
+					Targets: []infrav1.Target{
+						{
+							Service:   infrav1.ServiceReference{Name: "podinfo", Port: infrav1.ServicePort{Name: "http"}},
+							Namespace: namespaceName,
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, receiver)).Should(Succeed())
+		})
+
+		It("does not permit the target without a WebhookTargetGrant", func() {
+			eventuallyTargetReason(context.Background(), infrav1.TargetNotPermittedReason)
+		})
+
+		It("permits the target once a WebhookTargetGrant is created", func() {
+			ctx := context.Background()
+			Expect(k8sClient.Create(ctx, &infrav1.WebhookTargetGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: namespaceName},
+				Spec: infrav1.WebhookTargetGrantSpec{
+					From: []infrav1.WebhookTargetGrantFrom{{Namespace: "default"}},
+				},
+			})).Should(Succeed())
+
+			eventuallyTargetReason(ctx, infrav1.TargetResolvedReason)
+		})
+
+		It("cleans up", func() {
+			Expect(k8sClient.Delete(context.Background(), receiver)).Should(Succeed())
+		})
+	})
 })
+
+func TestExtendWithTargets(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	NewWithT(t).Expect(clientgoscheme.AddToScheme(testScheme)).To(Succeed())
+	NewWithT(t).Expect(infrav1.AddToScheme(testScheme)).To(Succeed())
+
+	portName := "http"
+	otherPortName := "grpc"
+
+	objects := []client.Object{
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a"}}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"team": "a"}}},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "default"},
+			Spec: v1.ServiceSpec{
+				ClusterIP: "10.0.0.1",
+				Ports:     []v1.ServicePort{{Name: portName, Port: 9898}},
+			},
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "headless", Namespace: "default"},
+			Spec: v1.ServiceSpec{
+				ClusterIP: v1.ClusterIPNone,
+				Ports:     []v1.ServicePort{{Name: portName, Port: 80}},
+			},
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "team-a"},
+			Spec: v1.ServiceSpec{
+				ClusterIP: "10.0.0.2",
+				Ports:     []v1.ServicePort{{Name: portName, Port: 9898}},
+			},
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "consumer-a", Namespace: "team-a", Labels: map[string]string{"webhooks/github": "true"}},
+			Spec: v1.ServiceSpec{
+				ClusterIP: "10.0.0.3",
+				Ports:     []v1.ServicePort{{Name: portName, Port: 80}},
+			},
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "consumer-b", Namespace: "team-b", Labels: map[string]string{"webhooks/github": "true"}},
+			Spec: v1.ServiceSpec{
+				ClusterIP: "10.0.0.4",
+				Ports:     []v1.ServicePort{{Name: otherPortName, Port: 8080}},
+			},
+		},
+		&infrav1.WebhookTargetGrant{
+			ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "team-a"},
+			Spec: infrav1.WebhookTargetGrantSpec{
+				From: []infrav1.WebhookTargetGrantFrom{{Namespace: "default"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		targets           []infrav1.Target
+		expectedTargets   []infrav1.TargetStatus
+		expectedCondition metav1.Condition
+	}{
+		{
+			name: "All targets resolved",
+			targets: []infrav1.Target{
+				{Name: "podinfo", Service: infrav1.ServiceReference{Name: "podinfo", Port: infrav1.ServicePort{Name: portName}}},
+			},
+			expectedTargets: []infrav1.TargetStatus{
+				{Name: "podinfo", Service: "podinfo", Namespace: "default", Port: 9898, Resolved: true, Reason: infrav1.TargetResolvedReason},
+			},
+			expectedCondition: metav1.Condition{
+				Type:    infrav1.ConditionTargetsResolved,
+				Status:  metav1.ConditionTrue,
+				Reason:  infrav1.AllTargetsResolvedReason,
+				Message: "1 of 1 targets resolved",
+			},
+		},
+		{
+			name: "Targets partially resolved",
+			targets: []infrav1.Target{
+				{Service: infrav1.ServiceReference{Name: "podinfo", Port: infrav1.ServicePort{Name: portName}}},
+				{Service: infrav1.ServiceReference{Name: "podinfo", Port: infrav1.ServicePort{Name: otherPortName}}},
+				{Service: infrav1.ServiceReference{Name: "headless", Port: infrav1.ServicePort{Name: portName}}},
+				{Service: infrav1.ServiceReference{Name: "does-not-exist", Port: infrav1.ServicePort{Number: 80}}},
+			},
+			expectedTargets: []infrav1.TargetStatus{
+				{Service: "podinfo", Namespace: "default", Port: 9898, Resolved: true, Reason: infrav1.TargetResolvedReason},
+				{Service: "podinfo", Namespace: "default", Reason: infrav1.PortNotFoundReason, Message: "port grpc not found"},
+				{Service: "headless", Namespace: "default", Port: 80, Reason: infrav1.HeadlessServiceReason, Message: "service has no cluster ip"},
+				{Service: "does-not-exist", Namespace: "default", Reason: infrav1.ServiceNotFoundReason, Message: "service not found"},
+			},
+			expectedCondition: metav1.Condition{
+				Type:    infrav1.ConditionTargetsResolved,
+				Status:  metav1.ConditionFalse,
+				Reason:  infrav1.TargetsPartiallyResolvedReason,
+				Message: "1 of 4 targets resolved",
+			},
+		},
+		{
+			name: "No targets resolved",
+			targets: []infrav1.Target{
+				{
+					Service:           infrav1.ServiceReference{Name: "podinfo", Port: infrav1.ServicePort{Name: portName}},
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "does-not-exist"}},
+				},
+			},
+			expectedTargets: []infrav1.TargetStatus{
+				{Service: "podinfo", Reason: infrav1.NamespaceNotSelectedReason, Message: "namespace selector does not match any namespace"},
+			},
+			expectedCondition: metav1.Condition{
+				Type:    infrav1.ConditionTargetsResolved,
+				Status:  metav1.ConditionFalse,
+				Reason:  infrav1.NoTargetsResolvedReason,
+				Message: "0 of 1 targets resolved",
+			},
+		},
+		{
+			name: "Targets are resolved per selected namespace",
+			targets: []infrav1.Target{
+				{
+					Service:           infrav1.ServiceReference{Name: "podinfo", Port: infrav1.ServicePort{Name: portName}},
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				},
+			},
+			expectedTargets: []infrav1.TargetStatus{
+				{Service: "podinfo", Namespace: "team-a", Port: 9898, Resolved: true, Reason: infrav1.TargetResolvedReason},
+				{Service: "podinfo", Namespace: "team-b", Reason: infrav1.ServiceNotFoundReason, Message: "service not found"},
+			},
+			expectedCondition: metav1.Condition{
+				Type:    infrav1.ConditionTargetsResolved,
+				Status:  metav1.ConditionFalse,
+				Reason:  infrav1.TargetsPartiallyResolvedReason,
+				Message: "1 of 2 targets resolved",
+			},
+		},
+		{
+			name: "Services are selected by labels in all selected namespaces",
+			targets: []infrav1.Target{
+				{
+					Service:           infrav1.ServiceReference{Port: infrav1.ServicePort{Name: portName}},
+					ServiceSelector:   &metav1.LabelSelector{MatchLabels: map[string]string{"webhooks/github": "true"}},
+					NamespaceSelector: &metav1.LabelSelector{},
+				},
+			},
+			expectedTargets: []infrav1.TargetStatus{
+				{Service: "", Namespace: "default", Reason: infrav1.ServiceNotFoundReason, Message: "no service matches the service selector"},
+				{Service: "consumer-a", Namespace: "team-a", Port: 80, Resolved: true, Reason: infrav1.TargetResolvedReason},
+				{Service: "consumer-b", Namespace: "team-b", Reason: infrav1.TargetNotPermittedReason, Message: "no WebhookTargetGrant in namespace team-b permits Receivers from namespace default"},
+			},
+			expectedCondition: metav1.Condition{
+				Type:    infrav1.ConditionTargetsResolved,
+				Status:  metav1.ConditionFalse,
+				Reason:  infrav1.TargetsPartiallyResolvedReason,
+				Message: "1 of 3 targets resolved",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			r := &ReceiverReconciler{
+				Client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(objects...).Build(),
+			}
+
+			receiver := infrav1.Receiver{
+				ObjectMeta: metav1.ObjectMeta{Name: "receiver", Namespace: "default"},
+				Spec:       infrav1.ReceiverSpec{Targets: test.targets},
+			}
+
+			_, err := r.extendWithTargets(context.Background(), &receiver, logr.Discard())
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(receiver.Status.Targets).To(Equal(test.expectedTargets))
+
+			condition := apimeta.FindStatusCondition(receiver.Status.Conditions, infrav1.ConditionTargetsResolved)
+			g.Expect(condition).NotTo(BeNil())
+			condition.LastTransitionTime = metav1.Time{}
+			g.Expect(*condition).To(Equal(test.expectedCondition))
+		})
+	}
+}
+
+func TestRequestsForChange(t *testing.T) {
+	portName := "http"
+	receivers := []client.Object{
+		&infrav1.Receiver{
+			ObjectMeta: metav1.ObjectMeta{Name: "same-namespace", Namespace: "default"},
+			Spec: infrav1.ReceiverSpec{Targets: []infrav1.Target{
+				{Service: infrav1.ServiceReference{Name: "podinfo", Port: infrav1.ServicePort{Name: portName}}},
+			}},
+		},
+		&infrav1.Receiver{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "default"},
+			Spec: infrav1.ReceiverSpec{Targets: []infrav1.Target{
+				{
+					Service:           infrav1.ServiceReference{Name: "podinfo", Port: infrav1.ServicePort{Name: portName}},
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				},
+			}},
+		},
+		&infrav1.Receiver{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-service", Namespace: "default"},
+			Spec: infrav1.ReceiverSpec{Targets: []infrav1.Target{
+				{
+					Service:           infrav1.ServiceReference{Name: "echo", Port: infrav1.ServicePort{Name: portName}},
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}},
+				},
+			}},
+		},
+		&infrav1.Receiver{
+			ObjectMeta: metav1.ObjectMeta{Name: "target-namespace", Namespace: "default"},
+			Spec: infrav1.ReceiverSpec{Targets: []infrav1.Target{
+				{
+					Service:   infrav1.ServiceReference{Name: "consumer-c", Port: infrav1.ServicePort{Name: portName}},
+					Namespace: "team-c",
+				},
+			}},
+		},
+		&infrav1.Receiver{
+			ObjectMeta: metav1.ObjectMeta{Name: "service-selector", Namespace: "default"},
+			Spec: infrav1.ReceiverSpec{Targets: []infrav1.Target{
+				{
+					Service:           infrav1.ServiceReference{Port: infrav1.ServicePort{Name: portName}},
+					ServiceSelector:   &metav1.LabelSelector{MatchLabels: map[string]string{"webhooks/github": "true"}},
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"webhooks": "enabled"}},
+				},
+			}},
+		},
+	}
+
+	testScheme := runtime.NewScheme()
+	NewWithT(t).Expect(clientgoscheme.AddToScheme(testScheme)).To(Succeed())
+	NewWithT(t).Expect(infrav1.AddToScheme(testScheme)).To(Succeed())
+
+	newReconciler := func(objects ...client.Object) *ReceiverReconciler {
+		return &ReceiverReconciler{
+			Log: logr.Discard(),
+			Client: fake.NewClientBuilder().
+				WithScheme(testScheme).
+				WithObjects(append(objects, receivers...)...).
+				WithIndex(&infrav1.Receiver{}, targetServiceIndex, indexTargetServices).
+				WithIndex(&infrav1.Receiver{}, serviceSelectorIndex, indexServiceSelector).
+				WithIndex(&infrav1.Receiver{}, namespaceSelectorIndex, indexNamespaceSelector).
+				WithIndex(&infrav1.Receiver{}, targetNamespaceIndex, indexTargetNamespaces).
+				Build(),
+		}
+	}
+
+	request := func(name string) reconcile.Request {
+		return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}}
+	}
+
+	t.Run("Service changes are mapped to Receivers targeting the service", func(t *testing.T) {
+		g := NewWithT(t)
+		r := newReconciler(
+			&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a", "webhooks": "enabled"}}},
+		)
+
+		g.Expect(r.requestsForChangeBySelector(context.Background(), &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "default"},
+		})).To(ConsistOf(request("same-namespace")))
+
+		g.Expect(r.requestsForChangeBySelector(context.Background(), &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "team-a"},
+		})).To(ConsistOf(request("team-a")))
+
+		g.Expect(r.requestsForChangeBySelector(context.Background(), &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "consumer", Namespace: "team-a", Labels: map[string]string{"webhooks/github": "true"}},
+		})).To(ConsistOf(request("service-selector")))
+
+		g.Expect(r.requestsForChangeBySelector(context.Background(), &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "default", Labels: map[string]string{"webhooks/github": "true"}},
+		})).To(ConsistOf(request("same-namespace")), "the namespace is not selected")
+	})
+
+	t.Run("Namespace label changes are mapped to Receivers selecting the old or new labels", func(t *testing.T) {
+		g := NewWithT(t)
+		r := newReconciler()
+
+		g.Expect(r.requestsForNamespaceLabels(context.Background(), map[string]string{"team": "a"})).To(ConsistOf(request("team-a")))
+		g.Expect(r.requestsForNamespaceLabels(context.Background(), map[string]string{"team": "a"}, map[string]string{"team": "b"})).To(ConsistOf(request("team-a"), request("other-service")))
+		g.Expect(r.requestsForNamespaceLabels(context.Background(), map[string]string{"webhooks": "enabled"})).To(ConsistOf(request("service-selector")))
+		g.Expect(r.requestsForNamespaceLabels(context.Background(), map[string]string{"team": "c"})).To(BeEmpty())
+	})
+
+	t.Run("WebhookTargetGrant changes are mapped to Receivers from the granted namespaces", func(t *testing.T) {
+		g := NewWithT(t)
+		r := newReconciler()
+
+		g.Expect(r.requestsForGrant(context.Background(), &infrav1.WebhookTargetGrant{
+			ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: "team-a"},
+			Spec: infrav1.WebhookTargetGrantSpec{
+				From: []infrav1.WebhookTargetGrantFrom{{Namespace: "default"}},
+			},
+		})).To(ConsistOf(request("team-a"), request("other-service"), request("service-selector")))
+
+		g.Expect(r.requestsForGrant(context.Background(), &infrav1.WebhookTargetGrant{
+			ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: "team-c"},
+			Spec: infrav1.WebhookTargetGrantSpec{
+				From: []infrav1.WebhookTargetGrantFrom{{Namespace: "default"}},
+			},
+		})).To(ConsistOf(request("team-a"), request("other-service"), request("service-selector"), request("target-namespace")))
+
+		g.Expect(r.requestsForGrant(context.Background(), &infrav1.WebhookTargetGrant{
+			ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: "team-a"},
+			Spec: infrav1.WebhookTargetGrantSpec{
+				From: []infrav1.WebhookTargetGrantFrom{{Namespace: "other"}},
+			},
+		})).To(BeEmpty())
+	})
+}
@@ -0,0 +1,188 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// +kubebuilder:webhook:path=/validate-webhook-infra-doodle-com-v1-receiver,mutating=false,failurePolicy=fail,sideEffects=None,groups=webhook.infra.doodle.com,resources=receivers,verbs=create;update,versions=v1,name=vreceiver.webhook.infra.doodle.com,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-webhook-infra-doodle-com-v1-clusterreceiver,mutating=false,failurePolicy=fail,sideEffects=None,groups=webhook.infra.doodle.com,resources=clusterreceivers,verbs=create;update,versions=v1,name=vclusterreceiver.webhook.infra.doodle.com,admissionReviewVersions=v1
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "github.com/DoodleScheduling/webhook-controller/api/v1"
+	"github.com/DoodleScheduling/webhook-controller/internal/proxy"
+)
+
+// ReceiverValidator validates Receivers and ClusterReceivers on admission.
+// It rejects specs which would otherwise only fail during reconciliation and warns about
+// targets referencing services which do not exist or are not permitted by a WebhookTargetGrant.
+type ReceiverValidator struct {
+	client.Reader
+
+	AllowCrossNamespaceTargets bool
+}
+
+var _ admission.CustomValidator = &ReceiverValidator{}
+
+// SetupWebhookWithManager registers the validating webhooks for Receivers and ClusterReceivers.
+// The conversion webhook between the API versions is registered along with them.
+func (v *ReceiverValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if err := ctrl.NewWebhookManagedBy(mgr).
+		For(&infrav1.Receiver{}).
+		WithValidator(v).
+		Complete(); err != nil {
+		return err
+	}
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&infrav1.ClusterReceiver{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate validates a created Receiver or ClusterReceiver
+func (v *ReceiverValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return v.validate(ctx, obj)
+}
+
+// ValidateUpdate validates an updated Receiver or ClusterReceiver
+func (v *ReceiverValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	return v.validate(ctx, newObj)
+}
+
+// ValidateDelete does not validate anything, deletions are always allowed
+func (v *ReceiverValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *ReceiverValidator) validate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	var gk schema.GroupKind
+	switch obj.(type) {
+	case *infrav1.Receiver:
+		gk = infrav1.GroupVersion.WithKind("Receiver").GroupKind()
+	case *infrav1.ClusterReceiver:
+		gk = infrav1.GroupVersion.WithKind("ClusterReceiver").GroupKind()
+	default:
+		return nil, fmt.Errorf("expected a Receiver or ClusterReceiver, got %T", obj)
+	}
+
+	receiver := obj.(receiverObject)
+	if errs := validateReceiverSpec(receiver); len(errs) > 0 {
+		return nil, errors.NewInvalid(gk, receiver.GetName(), errs)
+	}
+
+	return v.targetWarnings(ctx, receiver)
+}
+
+// validateReceiverSpec checks everything which can not be expressed as a CEL rule of the CRD
+func validateReceiverSpec(receiver receiverObject) field.ErrorList {
+	var errs field.ErrorList
+	clusterScoped := receiver.GetNamespace() == ""
+	targetsPath := field.NewPath("spec", "targets")
+
+	for i, target := range receiver.GetSpec().Targets {
+		path := targetsPath.Index(i)
+
+		if err := validateTarget(target, clusterScoped); err != nil {
+			errs = append(errs, field.Invalid(path, field.OmitValueType{}, err.Error()))
+		}
+
+		if target.ServiceSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(target.ServiceSelector); err != nil {
+				errs = append(errs, field.Invalid(path.Child("serviceSelector"), target.ServiceSelector, err.Error()))
+			}
+		}
+
+		if target.NamespaceSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(target.NamespaceSelector); err != nil {
+				errs = append(errs, field.Invalid(path.Child("namespaceSelector"), target.NamespaceSelector, err.Error()))
+			}
+		}
+
+		for j, code := range target.SuccessCodes {
+			if _, err := proxy.ParseStatusCodeRange(string(code)); err != nil {
+				errs = append(errs, field.Invalid(path.Child("successCodes").Index(j), code, err.Error()))
+			}
+		}
+
+		for j, code := range target.FailureCodes {
+			if _, err := proxy.ParseStatusCodeRange(string(code)); err != nil {
+				errs = append(errs, field.Invalid(path.Child("failureCodes").Index(j), code, err.Error()))
+			}
+		}
+
+		if target.SuccessExpression != "" {
+			if _, err := proxy.CompileExpression(target.SuccessExpression); err != nil {
+				errs = append(errs, field.Invalid(path.Child("successExpression"), target.SuccessExpression, err.Error()))
+			}
+		}
+	}
+
+	return errs
+}
+
+// targetWarnings warns about targets referencing services by name which do not exist or which the
+// receiver is not permitted to target. These are no errors since the service or the grant may be created later.
+func (v *ReceiverValidator) targetWarnings(ctx context.Context, receiver receiverObject) (admission.Warnings, error) {
+	var warnings admission.Warnings
+
+	for _, target := range receiver.GetSpec().Targets {
+		if target.Service.Name == "" || target.NamespaceSelector != nil {
+			continue
+		}
+
+		namespace := target.Namespace
+		if namespace == "" {
+			namespace = receiver.GetNamespace()
+		}
+
+		if namespace == "" {
+			continue
+		}
+
+		var svc v1.Service
+		err := v.Get(ctx, client.ObjectKey{Namespace: namespace, Name: target.Service.Name}, &svc)
+		switch {
+		case errors.IsNotFound(err):
+			warnings = append(warnings, fmt.Sprintf("service %s/%s does not exist", namespace, target.Service.Name))
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		permitted, err := targetPermitted(ctx, v, v.AllowCrossNamespaceTargets, receiver.GetNamespace(), svc)
+		if err != nil {
+			return nil, err
+		}
+
+		if !permitted {
+			warnings = append(warnings, fmt.Sprintf("service %s/%s is not permitted by a WebhookTargetGrant", namespace, target.Service.Name))
+		}
+	}
+
+	return warnings, nil
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "github.com/DoodleScheduling/webhook-controller/api/v1"
+)
+
+func TestReceiverValidator(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	NewWithT(t).Expect(clientgoscheme.AddToScheme(testScheme)).To(Succeed())
+	NewWithT(t).Expect(infrav1.AddToScheme(testScheme)).To(Succeed())
+
+	portName := "http"
+	port := infrav1.ServicePort{Name: portName}
+
+	objects := []client.Object{
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "default"}},
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "team-a"}},
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "team-b"}},
+		&infrav1.WebhookTargetGrant{
+			ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "team-a"},
+			Spec: infrav1.WebhookTargetGrantSpec{
+				From: []infrav1.WebhookTargetGrantFrom{{Namespace: "default"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		receiver         client.Object
+		expectedWarnings admission.Warnings
+		expectInvalid    bool
+	}{
+		{
+			name: "Valid receiver",
+			receiver: &infrav1.Receiver{
+				ObjectMeta: metav1.ObjectMeta{Name: "receiver", Namespace: "default"},
+				Spec: infrav1.ReceiverSpec{Targets: []infrav1.Target{
+					{Service: infrav1.ServiceReference{Name: "podinfo", Port: port}, SuccessCodes: []infrav1.StatusCodeRange{"200-299"}},
+					{Service: infrav1.ServiceReference{Name: "podinfo", Port: port}, Namespace: "team-a"},
+				}},
+			},
+		},
+		{
+			name: "Service and serviceSelector are mutually exclusive",
+			receiver: &infrav1.Receiver{
+				ObjectMeta: metav1.ObjectMeta{Name: "receiver", Namespace: "default"},
+				Spec: infrav1.ReceiverSpec{Targets: []infrav1.Target{
+					{Service: infrav1.ServiceReference{Name: "podinfo", Port: port}, ServiceSelector: &metav1.LabelSelector{}},
+				}},
+			},
+			expectInvalid: true,
+		},
+		{
+			name: "Invalid service selector",
+			receiver: &infrav1.Receiver{
+				ObjectMeta: metav1.ObjectMeta{Name: "receiver", Namespace: "default"},
+				Spec: infrav1.ReceiverSpec{Targets: []infrav1.Target{
+					{Service: infrav1.ServiceReference{Port: port}, ServiceSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "app", Operator: "Unknown"}},
+					}},
+				}},
+			},
+			expectInvalid: true,
+		},
+		{
+			name: "Invalid status code range",
+			receiver: &infrav1.Receiver{
+				ObjectMeta: metav1.ObjectMeta{Name: "receiver", Namespace: "default"},
+				Spec: infrav1.ReceiverSpec{Targets: []infrav1.Target{
+					{Service: infrav1.ServiceReference{Name: "podinfo", Port: port}, FailureCodes: []infrav1.StatusCodeRange{"600-700"}},
+				}},
+			},
+			expectInvalid: true,
+		},
+		{
+			name: "Invalid success expression",
+			receiver: &infrav1.Receiver{
+				ObjectMeta: metav1.ObjectMeta{Name: "receiver", Namespace: "default"},
+				Spec: infrav1.ReceiverSpec{Targets: []infrav1.Target{
+					{Service: infrav1.ServiceReference{Name: "podinfo", Port: port}, SuccessExpression: "response.code =="},
+				}},
+			},
+			expectInvalid: true,
+		},
+		{
+			name: "ClusterReceiver target without namespace",
+			receiver: &infrav1.ClusterReceiver{
+				ObjectMeta: metav1.ObjectMeta{Name: "receiver"},
+				Spec: infrav1.ReceiverSpec{Targets: []infrav1.Target{
+					{Service: infrav1.ServiceReference{Name: "podinfo", Port: port}},
+				}},
+			},
+			expectInvalid: true,
+		},
+		{
+			name: "Warns about missing and not permitted services",
+			receiver: &infrav1.Receiver{
+				ObjectMeta: metav1.ObjectMeta{Name: "receiver", Namespace: "default"},
+				Spec: infrav1.ReceiverSpec{Targets: []infrav1.Target{
+					{Service: infrav1.ServiceReference{Name: "missing", Port: port}},
+					{Service: infrav1.ServiceReference{Name: "podinfo", Port: port}, Namespace: "team-b"},
+				}},
+			},
+			expectedWarnings: admission.Warnings{
+				"service default/missing does not exist",
+				"service team-b/podinfo is not permitted by a WebhookTargetGrant",
+			},
+		},
+		{
+			name: "ClusterReceivers are permitted to target any namespace",
+			receiver: &infrav1.ClusterReceiver{
+				ObjectMeta: metav1.ObjectMeta{Name: "receiver"},
+				Spec: infrav1.ReceiverSpec{Targets: []infrav1.Target{
+					{Service: infrav1.ServiceReference{Name: "podinfo", Port: port}, Namespace: "team-b"},
+				}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			validator := &ReceiverValidator{
+				Reader: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(objects...).Build(),
+			}
+
+			warnings, err := validator.ValidateCreate(context.TODO(), test.receiver)
+			if test.expectInvalid {
+				g.Expect(errors.IsInvalid(err)).To(BeTrue(), "expected an invalid error, got %v", err)
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(warnings).To(Equal(test.expectedWarnings))
+		})
+	}
+}
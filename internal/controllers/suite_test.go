@@ -23,7 +23,7 @@ import (
 	"path/filepath"
 	"testing"
 
-	infrav1beta1 "github.com/DoodleScheduling/webhook-controller/api/v1beta1"
+	infrav1 "github.com/DoodleScheduling/webhook-controller/api/v1"
 	"github.com/DoodleScheduling/webhook-controller/internal/proxy"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -69,7 +69,7 @@ var _ = BeforeSuite(func() {
 	err = corev1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
-	err = infrav1beta1.AddToScheme(scheme.Scheme)
+	err = infrav1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
 	k8sManager, err = ctrl.NewManager(cfg, ctrl.Options{
@@ -85,6 +85,16 @@ var _ = BeforeSuite(func() {
 	}).SetupWithManager(k8sManager, ReceiverReconcilerOptions{})
 	Expect(err).ToNot(HaveOccurred())
 
+	err = (&ClusterReceiverReconciler{
+		ReceiverReconciler: ReceiverReconciler{
+			HttpProxy: proxy.New(proxy.DefaultOptions),
+			Client:    k8sManager.GetClient(),
+			Log:       ctrl.Log.WithName("controllers").WithName("ClusterReceiver"),
+			Recorder:  k8sManager.GetEventRecorderFor("ClusterReceiver"),
+		},
+	}).SetupWithManager(k8sManager, ReceiverReconcilerOptions{})
+	Expect(err).ToNot(HaveOccurred())
+
 	ctx, cancel = context.WithCancel(context.TODO())
 	go func() {
 		defer GinkgoRecover()
@@ -0,0 +1,333 @@
+package proxy
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxRecentErrors is the number of failed deliveries and dead letters kept for the admin api
+const maxRecentErrors = 100
+
+// DefaultDeadLetterBodySizeLimit is the number of body bytes kept per dead letter
+const DefaultDeadLetterBodySizeLimit = 64 * 1024
+
+// redactedHeaderValue replaces the values of sensitive headers in dead letters
+const redactedHeaderValue = "REDACTED"
+
+// sensitiveHeaderPatterns redact dead letter headers containing any of them, like Authorization or X-Hub-Signature-256
+var sensitiveHeaderPatterns = []string{"auth", "cookie", "token", "signature", "secret", "key", "password", "session"}
+
+type AdminReceiver struct {
+	Namespace         string        `json:"namespace,omitempty"`
+	Name              string        `json:"name,omitempty"`
+	PathFingerprint   string        `json:"pathFingerprint"`
+	ResponseType      ResponseType  `json:"responseType"`
+	Timeout           string        `json:"timeout"`
+	BodySizeLimit     int64         `json:"bodySizeLimit,omitempty"`
+	Streaming         bool          `json:"streaming,omitempty"`
+	Ordered           bool          `json:"ordered,omitempty"`
+	PendingDeliveries int64         `json:"pendingDeliveries"`
+	Targets           []AdminTarget `json:"targets"`
+}
+
+type AdminTarget struct {
+	Service   string `json:"service"`
+	Namespace string `json:"namespace"`
+	Address   string `json:"address"`
+	Port      int32  `json:"port"`
+	Path      string `json:"path,omitempty"`
+	Primary   bool   `json:"primary,omitempty"`
+
+	Timeout       string `json:"timeout,omitempty"`
+	BodySizeLimit int64  `json:"bodySizeLimit,omitempty"`
+	FireAndForget bool   `json:"fireAndForget,omitempty"`
+
+	CircuitState   CircuitState `json:"circuitState,omitempty"`
+	Delay          string       `json:"delay,omitempty"`
+	DebounceWindow string       `json:"debounceWindow,omitempty"`
+	Sampling       string       `json:"sampling,omitempty"`
+}
+
+type AdminDeliveries struct {
+	InFlight     int64             `json:"inFlight"`
+	Queues       []AdminQueue      `json:"queues"`
+	RecentErrors []DeliveryFailure `json:"recentErrors"`
+}
+
+// AdminQueue is the number of target deliveries of a receiver which are not finished yet
+type AdminQueue struct {
+	Namespace       string `json:"namespace,omitempty"`
+	Name            string `json:"name,omitempty"`
+	PathFingerprint string `json:"pathFingerprint"`
+	Depth           int64  `json:"depth"`
+}
+
+// DeliveryFailure is a delivery to a target which failed after all attempts
+type DeliveryFailure struct {
+	Time             time.Time `json:"time"`
+	Namespace        string    `json:"namespace,omitempty"`
+	Name             string    `json:"name,omitempty"`
+	PathFingerprint  string    `json:"pathFingerprint"`
+	Service          string    `json:"service"`
+	ServiceNamespace string    `json:"serviceNamespace"`
+	StatusCode       int       `json:"statusCode"`
+	Attempts         int       `json:"attempts"`
+	Error            string    `json:"error,omitempty"`
+}
+
+// DeadLetter is a delivery which has not been sent since the circuit breaker of its target was open.
+// It contains the request as received to allow replaying it.
+type DeadLetter struct {
+	Time             time.Time   `json:"time"`
+	Namespace        string      `json:"namespace,omitempty"`
+	Name             string      `json:"name,omitempty"`
+	PathFingerprint  string      `json:"pathFingerprint"`
+	Service          string      `json:"service"`
+	ServiceNamespace string      `json:"serviceNamespace"`
+	Method           string      `json:"method"`
+	Path             string      `json:"path"`
+	Header           http.Header `json:"header,omitempty"`
+	Body             []byte      `json:"body,omitempty"`
+	BodyTruncated    bool        `json:"bodyTruncated,omitempty"`
+}
+
+// ringLog is a ring buffer of the most recent maxRecentErrors entries
+type ringLog[T any] struct {
+	mu      sync.Mutex
+	entries []T
+	next    int
+}
+
+func (l *ringLog[T]) add(entry T) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.entries) < maxRecentErrors {
+		l.entries = append(l.entries, entry)
+		return
+	}
+
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % maxRecentErrors
+}
+
+// list returns the entries, the most recent first
+func (l *ringLog[T]) list() []T {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := make([]T, 0, len(l.entries))
+	entries = append(entries, l.entries[l.next:]...)
+	entries = append(entries, l.entries[:l.next]...)
+	slices.Reverse(entries)
+	return entries
+}
+
+// fingerprint identifies a webhook path without exposing it
+func fingerprint(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// pendingDeliveries returns the counter of unfinished target deliveries of a receiver
+func (h *HttpProxy) pendingDeliveries(path string) *atomic.Int64 {
+	counter, _ := h.pending.LoadOrStore(path, &atomic.Int64{})
+	return counter.(*atomic.Int64)
+}
+
+func (h *HttpProxy) recordFailure(receiver Receiver, result targetResult) {
+	namespace, name := receiver.owner()
+	failure := DeliveryFailure{
+		Time:             time.Now(),
+		Namespace:        namespace,
+		Name:             name,
+		PathFingerprint:  fingerprint(receiver.Path),
+		Service:          result.target.ServiceName,
+		ServiceNamespace: result.target.ServiceNamespace,
+		StatusCode:       result.response.StatusCode,
+		Attempts:         result.attempts,
+	}
+
+	if result.err != nil {
+		failure.Error = result.err.Error()
+	}
+
+	h.failures.add(failure)
+}
+
+// recordDeadLetter keeps the request which could not be delivered to the target, the body is read up to the dead letter body size limit.
+// Sensitive headers are redacted.
+func (h *HttpProxy) recordDeadLetter(receiver Receiver, dst Target, req *http.Request, body io.Reader) {
+	namespace, name := receiver.owner()
+	deadLetter := DeadLetter{
+		Time:             time.Now(),
+		Namespace:        namespace,
+		Name:             name,
+		PathFingerprint:  fingerprint(receiver.Path),
+		Service:          dst.ServiceName,
+		ServiceNamespace: dst.ServiceNamespace,
+		Method:           req.Method,
+		Path:             req.URL.Path,
+		Header:           h.redactHeader(req.Header),
+	}
+
+	limit := h.deadLetterBodySizeLimit
+	b, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		h.log.Error(err, "failed to read dead letter body", "service", dst.ServiceName, "namespace", dst.ServiceNamespace)
+	}
+
+	if int64(len(b)) > limit {
+		b = b[:limit]
+		deadLetter.BodyTruncated = true
+	}
+
+	deadLetter.Body = b
+	h.deadLetters.add(deadLetter)
+}
+
+// redactHeader returns a copy of the header with the values of sensitive headers redacted
+func (h *HttpProxy) redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for name := range redacted {
+		lower := strings.ToLower(name)
+		sensitive := slices.ContainsFunc(sensitiveHeaderPatterns, func(pattern string) bool {
+			return strings.Contains(lower, pattern)
+		})
+
+		if sensitive || slices.Contains(h.redactHeaders, http.CanonicalHeaderKey(name)) {
+			redacted[name] = []string{redactedHeaderValue}
+		}
+	}
+
+	return redacted
+}
+
+// AdminHandler serves the registered receivers and the state of the deliveries as json.
+// Webhook paths are secrets and only exposed as fingerprint.
+func (h *HttpProxy) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/receivers", h.serveAdminReceivers)
+	mux.HandleFunc("GET /admin/deliveries", h.serveAdminDeliveries)
+	mux.HandleFunc("GET /admin/deadletters", h.serveAdminDeadLetters)
+	return mux
+}
+
+func (h *HttpProxy) serveAdminReceivers(w http.ResponseWriter, _ *http.Request) {
+	receivers := []AdminReceiver{}
+	for path, receiver := range h.snapshot() {
+		namespace, name := receiver.owner()
+		adminReceiver := AdminReceiver{
+			Namespace:         namespace,
+			Name:              name,
+			PathFingerprint:   fingerprint(path),
+			ResponseType:      receiver.ResponseType,
+			Timeout:           receiver.Timeout.String(),
+			BodySizeLimit:     receiver.BodySizeLimit,
+			Streaming:         receiver.Streaming,
+			Ordered:           receiver.OrderingKey != nil,
+			PendingDeliveries: h.pendingDeliveries(path).Load(),
+			Targets:           []AdminTarget{},
+		}
+
+		for _, target := range receiver.Targets {
+			adminTarget := AdminTarget{
+				Service:       target.ServiceName,
+				Namespace:     target.ServiceNamespace,
+				Address:       target.Address,
+				Port:          target.Port,
+				Path:          target.Path,
+				Primary:       target.Primary,
+				BodySizeLimit: target.BodySizeLimit,
+				FireAndForget: target.FireAndForget,
+			}
+
+			if target.Timeout > 0 {
+				adminTarget.Timeout = target.Timeout.String()
+			}
+
+			adminTarget.CircuitState = h.breakers.state(path, target)
+			if target.Delay > 0 {
+				adminTarget.Delay = target.Delay.String()
+			}
+
+			if target.Debounce != nil {
+				adminTarget.DebounceWindow = target.Debounce.Window.String()
+			}
+
+			if target.Sampling != nil {
+				adminTarget.Sampling = fmt.Sprintf("%d%%", target.Sampling.Percentage)
+			}
+
+			adminReceiver.Targets = append(adminReceiver.Targets, adminTarget)
+		}
+
+		receivers = append(receivers, adminReceiver)
+	}
+
+	slices.SortFunc(receivers, func(a, b AdminReceiver) int {
+		return cmp.Or(
+			cmp.Compare(a.Namespace, b.Namespace),
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.PathFingerprint, b.PathFingerprint),
+		)
+	})
+
+	h.writeAdminResponse(w, receivers)
+}
+
+func (h *HttpProxy) serveAdminDeliveries(w http.ResponseWriter, _ *http.Request) {
+	deliveries := AdminDeliveries{
+		InFlight:     h.InFlight(),
+		Queues:       []AdminQueue{},
+		RecentErrors: h.failures.list(),
+	}
+
+	for path, receiver := range h.snapshot() {
+		namespace, name := receiver.owner()
+		deliveries.Queues = append(deliveries.Queues, AdminQueue{
+			Namespace:       namespace,
+			Name:            name,
+			PathFingerprint: fingerprint(path),
+			Depth:           h.pendingDeliveries(path).Load(),
+		})
+	}
+
+	slices.SortFunc(deliveries.Queues, func(a, b AdminQueue) int {
+		return cmp.Or(
+			cmp.Compare(a.Namespace, b.Namespace),
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.PathFingerprint, b.PathFingerprint),
+		)
+	})
+
+	h.writeAdminResponse(w, deliveries)
+}
+
+func (h *HttpProxy) serveAdminDeadLetters(w http.ResponseWriter, _ *http.Request) {
+	h.writeAdminResponse(w, h.deadLetters.list())
+}
+
+func (h *HttpProxy) writeAdminResponse(w http.ResponseWriter, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		h.log.Error(err, "failed to marshal admin response")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(body); err != nil {
+		h.log.Error(err, "failed to write admin response")
+	}
+}
@@ -0,0 +1,198 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestAdminHandler_Receivers(t *testing.T) {
+	g := NewWithT(t)
+	proxy := New(DefaultOptions)
+
+	g.Expect(proxy.RegisterOrUpdate(Receiver{
+		Path:         "/hooks/secret-b",
+		ResponseType: Async,
+		Timeout:      5 * time.Second,
+		Object:       &corev1.ObjectReference{Namespace: "default", Name: "b"},
+		Targets: []Target{
+			{ServiceName: "podinfo", ServiceNamespace: "default", Address: "10.0.0.1", Port: 9898, Path: "/api", Primary: true},
+		},
+	})).To(Succeed())
+
+	g.Expect(proxy.RegisterOrUpdate(Receiver{
+		Path:         "/hooks/secret-a",
+		ResponseType: AwaitAllReport,
+		Streaming:    true,
+		Object:       &corev1.ObjectReference{Namespace: "default", Name: "a"},
+	})).To(Succeed())
+
+	w := httptest.NewRecorder()
+	proxy.AdminHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/receivers", nil))
+
+	g.Expect(w.Code).To(Equal(http.StatusOK))
+	g.Expect(w.Header().Get("Content-Type")).To(Equal("application/json"))
+	g.Expect(w.Body.String()).NotTo(ContainSubstring("secret"), "webhook paths must not be exposed")
+
+	var receivers []AdminReceiver
+	g.Expect(json.Unmarshal(w.Body.Bytes(), &receivers)).To(Succeed())
+	g.Expect(receivers).To(Equal([]AdminReceiver{
+		{
+			Namespace:       "default",
+			Name:            "a",
+			PathFingerprint: fingerprint("/hooks/secret-a"),
+			ResponseType:    AwaitAllReport,
+			Timeout:         "0s",
+			Streaming:       true,
+			Targets:         []AdminTarget{},
+		},
+		{
+			Namespace:       "default",
+			Name:            "b",
+			PathFingerprint: fingerprint("/hooks/secret-b"),
+			ResponseType:    Async,
+			Timeout:         "5s",
+			Targets: []AdminTarget{
+				{Service: "podinfo", Namespace: "default", Address: "10.0.0.1", Port: 9898, Path: "/api", Primary: true},
+			},
+		},
+	}))
+
+	w = httptest.NewRecorder()
+	proxy.AdminHandler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/receivers", nil))
+	g.Expect(w.Code).To(Equal(http.StatusMethodNotAllowed))
+}
+
+func TestAdminHandler_Deliveries(t *testing.T) {
+	g := NewWithT(t)
+	release := make(chan struct{})
+
+	opts := DefaultOptions
+	opts.Client = &http.Client{
+		Transport: &dummyTransport{
+			transport: func(r *http.Request) (*http.Response, error) {
+				if r.URL.Host == "failing:8080" {
+					return nil, errors.New("connection refused")
+				}
+
+				<-release
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+			},
+		},
+	}
+
+	proxy := New(opts)
+	defer proxy.Close()
+
+	g.Expect(proxy.RegisterOrUpdate(Receiver{
+		Path:         "/hook",
+		ResponseType: Async,
+		Object:       &corev1.ObjectReference{Namespace: "default", Name: "receiver"},
+		Targets: []Target{
+			{Address: "failing", Port: 8080, ServiceName: "failing", ServiceNamespace: "default"},
+			{Address: "blocking", Port: 8080, ServiceName: "blocking", ServiceNamespace: "default"},
+		},
+	})).To(Succeed())
+
+	w := httptest.NewRecorder()
+	proxy.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader("body")))
+	g.Expect(w.Code).To(Equal(http.StatusAccepted))
+
+	var deliveries AdminDeliveries
+	g.Eventually(func() error {
+		w := httptest.NewRecorder()
+		proxy.AdminHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/deliveries", nil))
+		if w.Code != http.StatusOK {
+			return fmt.Errorf("unexpected status code %d", w.Code)
+		}
+
+		if err := json.Unmarshal(w.Body.Bytes(), &deliveries); err != nil {
+			return err
+		}
+
+		if len(deliveries.RecentErrors) == 0 {
+			return errors.New("no failure recorded yet")
+		}
+
+		return nil
+	}).Should(Succeed())
+
+	g.Expect(deliveries.InFlight).To(Equal(int64(1)))
+	g.Expect(deliveries.Queues).To(Equal([]AdminQueue{
+		{Namespace: "default", Name: "receiver", PathFingerprint: fingerprint("/hook"), Depth: 1},
+	}))
+	g.Expect(deliveries.RecentErrors).To(HaveLen(1))
+
+	failure := deliveries.RecentErrors[0]
+	g.Expect(failure.Time).NotTo(BeZero())
+	g.Expect(failure.Error).To(ContainSubstring("connection refused"))
+	failure.Time, failure.Error = time.Time{}, ""
+	g.Expect(failure).To(Equal(DeliveryFailure{
+		Namespace:        "default",
+		Name:             "receiver",
+		PathFingerprint:  fingerprint("/hook"),
+		Service:          "failing",
+		ServiceNamespace: "default",
+		StatusCode:       http.StatusGatewayTimeout,
+		Attempts:         1,
+	}))
+
+	close(release)
+	g.Eventually(proxy.InFlight).Should(BeZero())
+	g.Expect(proxy.pendingDeliveries("/hook").Load()).To(BeZero())
+}
+
+func TestFailureLog(t *testing.T) {
+	g := NewWithT(t)
+	var log ringLog[DeliveryFailure]
+
+	for i := range maxRecentErrors + 10 {
+		log.add(DeliveryFailure{Attempts: i})
+	}
+
+	failures := log.list()
+	g.Expect(failures).To(HaveLen(maxRecentErrors))
+	g.Expect(failures[0].Attempts).To(Equal(maxRecentErrors+9), "the most recent failure comes first")
+	g.Expect(failures[maxRecentErrors-1].Attempts).To(Equal(10), "the oldest failures are dropped")
+}
+
+func TestRecordDeadLetter(t *testing.T) {
+	g := NewWithT(t)
+	opts := DefaultOptions
+	opts.DeadLetterBodySizeLimit = 4
+	opts.RedactHeaders = []string{"x-tenant"}
+	proxy := New(opts)
+
+	req, _ := http.NewRequest("POST", "http://target:8080/hook", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Hub-Signature-256", "sha256=abc")
+	req.Header.Set("X-Gitlab-Token", "secret")
+	req.Header.Set("Cookie", "session=secret")
+	req.Header.Set("X-Tenant", "team-a")
+	req.Header.Set("Content-Type", "application/json")
+
+	proxy.recordDeadLetter(Receiver{Path: "/hook"}, Target{ServiceName: "podinfo"}, req, strings.NewReader("body exceeding the limit"))
+
+	deadLetters := proxy.deadLetters.list()
+	g.Expect(deadLetters).To(HaveLen(1))
+	g.Expect(deadLetters[0].Header).To(Equal(http.Header{
+		"Authorization":       []string{"REDACTED"},
+		"X-Hub-Signature-256": []string{"REDACTED"},
+		"X-Gitlab-Token":      []string{"REDACTED"},
+		"Cookie":              []string{"REDACTED"},
+		"X-Tenant":            []string{"REDACTED"},
+		"Content-Type":        []string{"application/json"},
+	}))
+	g.Expect(string(deadLetters[0].Body)).To(Equal("body"))
+	g.Expect(deadLetters[0].BodyTruncated).To(BeTrue())
+	g.Expect(req.Header.Get("Authorization")).To(Equal("Bearer secret"), "the request header is not modified")
+}
@@ -0,0 +1,424 @@
+package proxy
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultCircuitWindow is the window the failure percentage of a circuit breaker is calculated over
+	DefaultCircuitWindow = time.Minute
+	// DefaultCircuitOpenDuration is the time a circuit stays open before probe deliveries are sent
+	DefaultCircuitOpenDuration = 30 * time.Second
+	// DefaultCircuitQueueTimeout is the maximum time a queued delivery waits for the circuit to close
+	DefaultCircuitQueueTimeout = 5 * time.Minute
+	// DefaultCircuitQueueLimit is the maximum number of deliveries queued per target
+	DefaultCircuitQueueLimit = 100
+	// DefaultCircuitMinimumDeliveries is the number of deliveries within the window required to evaluate the failure percentage
+	DefaultCircuitMinimumDeliveries = 10
+)
+
+var errCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of the circuit breaker of a target
+type CircuitState string
+
+const (
+	// CircuitClosed sends all deliveries to the target
+	CircuitClosed CircuitState = "Closed"
+	// CircuitOpen does not send any deliveries to the target
+	CircuitOpen CircuitState = "Open"
+	// CircuitHalfOpen sends a limited number of probe deliveries to find out whether the target recovered
+	CircuitHalfOpen CircuitState = "HalfOpen"
+)
+
+// circuitStates are all states of a circuit, each of them is exported as a series of the state metric
+var circuitStates = []CircuitState{CircuitClosed, CircuitOpen, CircuitHalfOpen}
+
+// CircuitOpenAction decides what happens with deliveries to a target while its circuit is open
+type CircuitOpenAction string
+
+const (
+	// CircuitSkip drops the delivery
+	CircuitSkip CircuitOpenAction = "Skip"
+	// CircuitQueue holds the delivery until the circuit closes or the queue timeout expires.
+	// The delivery is reported as failed to the response type right away.
+	CircuitQueue CircuitOpenAction = "Queue"
+	// CircuitDeadLetter drops the delivery and keeps the request in the dead letters of the admin api
+	CircuitDeadLetter CircuitOpenAction = "DeadLetter"
+)
+
+// CircuitBreakerPolicy defines when the circuit of a target opens.
+// The circuit opens after ConsecutiveFailures failed deliveries in a row or once FailurePercentage
+// of at least MinimumDeliveries deliveries within Window failed, a zero value disables the threshold.
+type CircuitBreakerPolicy struct {
+	ConsecutiveFailures int
+	FailurePercentage   int
+	MinimumDeliveries   int
+	Window              time.Duration
+	// OpenDuration is the time the circuit stays open before HalfOpenProbes probe deliveries are sent.
+	// The circuit closes once all of them succeeded and opens again on the first failed one.
+	OpenDuration   time.Duration
+	HalfOpenProbes int
+	OpenAction     CircuitOpenAction
+	QueueTimeout   time.Duration
+	// QueueLimit is the maximum number of queued deliveries, further deliveries are dead-lettered
+	QueueLimit int
+}
+
+// circuitBreaker tracks the deliveries to a target and decides whether further deliveries are sent
+type circuitBreaker struct {
+	mu     sync.Mutex
+	policy CircuitBreakerPolicy
+	state  CircuitState
+	// changed is closed and replaced whenever the state changes
+	changed  chan struct{}
+	onChange func(CircuitState)
+	// onQueue is called with the number of queued deliveries whenever it changes
+	onQueue func(int)
+	// onRemove is called once the breaker is removed, state changes afterwards are not reported anymore
+	onRemove func()
+	removed  bool
+	nowFunc  func() time.Time
+	queued   int
+
+	openedAt            time.Time
+	windowStart         time.Time
+	deliveries          int
+	failures            int
+	consecutiveFailures int
+	probes              int
+	probeSuccesses      int
+}
+
+func newCircuitBreaker(policy CircuitBreakerPolicy, onChange func(CircuitState)) *circuitBreaker {
+	b := &circuitBreaker{
+		policy:   policy,
+		state:    CircuitClosed,
+		changed:  make(chan struct{}),
+		onChange: onChange,
+	}
+
+	b.onChange(b.state)
+	return b
+}
+
+func (b *circuitBreaker) now() time.Time {
+	if b.nowFunc != nil {
+		return b.nowFunc()
+	}
+
+	return time.Now()
+}
+
+// getState returns the current state, an elapsed open duration is reported as HalfOpen
+func (b *circuitBreaker) getState() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.openDuration() {
+		return CircuitHalfOpen
+	}
+
+	return b.state
+}
+
+func (b *circuitBreaker) setPolicy(policy CircuitBreakerPolicy) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.policy = policy
+}
+
+func (b *circuitBreaker) openDuration() time.Duration {
+	return cmp.Or(b.policy.OpenDuration, DefaultCircuitOpenDuration)
+}
+
+func (b *circuitBreaker) setState(state CircuitState) {
+	b.state = state
+	close(b.changed)
+	b.changed = make(chan struct{})
+	if !b.removed {
+		b.onChange(state)
+	}
+}
+
+// remove stops reporting state changes of a breaker which is not used by a receiver anymore
+func (b *circuitBreaker) remove() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.removed = true
+	if b.onRemove != nil {
+		b.onRemove()
+	}
+}
+
+// allow reports whether a delivery may be sent and whether it is a probe delivery of a half open circuit.
+// A nil circuit breaker allows all deliveries.
+func (b *circuitBreaker) allow() (ok bool, probe bool) {
+	if b == nil {
+		return true, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.allowLocked()
+}
+
+func (b *circuitBreaker) allowLocked() (ok bool, probe bool) {
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.openDuration() {
+			return false, false
+		}
+
+		b.probes, b.probeSuccesses = 0, 0
+		b.setState(CircuitHalfOpen)
+		fallthrough
+	case CircuitHalfOpen:
+		if b.probes >= max(b.policy.HalfOpenProbes, 1) {
+			return false, false
+		}
+
+		b.probes++
+		return true, true
+	default:
+		return true, false
+	}
+}
+
+// enqueue reserves a place in the queue of the target and returns false if the queue is full.
+// A reserved place must be released with dequeue.
+func (b *circuitBreaker) enqueue() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.queued >= cmp.Or(b.policy.QueueLimit, DefaultCircuitQueueLimit) {
+		return false
+	}
+
+	b.queued++
+	b.reportQueue()
+	return true
+}
+
+// dequeue releases a place in the queue of the target
+func (b *circuitBreaker) dequeue() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.queued--
+	b.reportQueue()
+}
+
+func (b *circuitBreaker) reportQueue() {
+	if b.onQueue != nil && !b.removed {
+		b.onQueue(b.queued)
+	}
+}
+
+// wait blocks until a delivery may be sent or the context is done
+func (b *circuitBreaker) wait(ctx context.Context) (probe bool, err error) {
+	for {
+		b.mu.Lock()
+		ok, probe := b.allowLocked()
+		changed := b.changed
+		var retry <-chan time.Time
+		if b.state == CircuitOpen {
+			retry = time.After(b.openDuration() - b.now().Sub(b.openedAt))
+		}
+		b.mu.Unlock()
+
+		if ok {
+			return probe, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-changed:
+		case <-retry:
+		}
+	}
+}
+
+// record updates the circuit with the outcome of a delivery which has been allowed.
+// Outcomes of deliveries which were sent before the circuit opened are ignored.
+func (b *circuitBreaker) record(outcome outcome, probe bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	failed := outcome == outcomeFailure
+
+	switch {
+	case b.state == CircuitHalfOpen && probe:
+		if failed {
+			b.open(now)
+			return
+		}
+
+		b.probeSuccesses++
+		if b.probeSuccesses >= max(b.policy.HalfOpenProbes, 1) {
+			b.close(now)
+		}
+	case b.state == CircuitClosed:
+		if now.Sub(b.windowStart) >= cmp.Or(b.policy.Window, DefaultCircuitWindow) {
+			b.windowStart = now
+			b.deliveries, b.failures = 0, 0
+		}
+
+		b.deliveries++
+		if !failed {
+			b.consecutiveFailures = 0
+			return
+		}
+
+		b.failures++
+		b.consecutiveFailures++
+		if b.tripped() {
+			b.open(now)
+		}
+	}
+}
+
+// tripped reports whether any of the thresholds of the policy has been reached
+func (b *circuitBreaker) tripped() bool {
+	if b.policy.ConsecutiveFailures > 0 && b.consecutiveFailures >= b.policy.ConsecutiveFailures {
+		return true
+	}
+
+	minimum := cmp.Or(b.policy.MinimumDeliveries, DefaultCircuitMinimumDeliveries)
+	return b.policy.FailurePercentage > 0 && b.deliveries >= minimum &&
+		b.failures*100 >= b.policy.FailurePercentage*b.deliveries
+}
+
+func (b *circuitBreaker) open(now time.Time) {
+	b.openedAt = now
+	b.setState(CircuitOpen)
+}
+
+func (b *circuitBreaker) close(now time.Time) {
+	b.windowStart = now
+	b.deliveries, b.failures, b.consecutiveFailures = 0, 0, 0
+	b.setState(CircuitClosed)
+}
+
+type circuitKey struct {
+	address string
+	port    int32
+}
+
+// circuitBreakers holds the circuit breakers of the targets per receiver path
+type circuitBreakers struct {
+	mu       sync.Mutex
+	breakers map[string]map[circuitKey]*circuitBreaker
+}
+
+// get returns the circuit breaker of the target, nil if the target does not have a circuit breaker policy
+func (c *circuitBreakers) get(receiver Receiver, target Target) *circuitBreaker {
+	if target.CircuitBreaker == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.breakers == nil {
+		c.breakers = make(map[string]map[circuitKey]*circuitBreaker)
+	}
+
+	targets, ok := c.breakers[receiver.Path]
+	if !ok {
+		targets = make(map[circuitKey]*circuitBreaker)
+		c.breakers[receiver.Path] = targets
+	}
+
+	key := circuitKey{address: target.Address, port: target.Port}
+	breaker, ok := targets[key]
+	if !ok {
+		receiverNamespace, receiverName := receiver.owner()
+		breaker = newCircuitBreaker(*target.CircuitBreaker, func(state CircuitState) {
+			for _, s := range circuitStates {
+				value := 0.0
+				if s == state {
+					value = 1
+				}
+
+				targetCircuitState.WithLabelValues(receiverNamespace, receiverName, target.ServiceName, target.ServiceNamespace, string(s)).Set(value)
+			}
+		})
+
+		breaker.onQueue = func(depth int) {
+			targetCircuitQueueDepth.WithLabelValues(receiverNamespace, receiverName, target.ServiceName, target.ServiceNamespace).Set(float64(depth))
+		}
+
+		breaker.onRemove = func() {
+			for _, s := range circuitStates {
+				targetCircuitState.DeleteLabelValues(receiverNamespace, receiverName, target.ServiceName, target.ServiceNamespace, string(s))
+			}
+
+			targetCircuitQueueDepth.DeleteLabelValues(receiverNamespace, receiverName, target.ServiceName, target.ServiceNamespace)
+		}
+
+		targets[key] = breaker
+		return breaker
+	}
+
+	breaker.setPolicy(*target.CircuitBreaker)
+	return breaker
+}
+
+// state returns the circuit state of the target, empty if it has no circuit breaker
+func (c *circuitBreakers) state(path string, target Target) CircuitState {
+	c.mu.Lock()
+	breaker, ok := c.breakers[path][circuitKey{address: target.Address, port: target.Port}]
+	c.mu.Unlock()
+
+	if !ok || target.CircuitBreaker == nil {
+		return ""
+	}
+
+	return breaker.getState()
+}
+
+// prune removes the circuit breakers of targets which are not part of the receiver anymore
+func (c *circuitBreakers) prune(receiver Receiver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, breaker := range c.breakers[receiver.Path] {
+		if !receiver.hasCircuitBreaker(key) {
+			breaker.remove()
+			delete(c.breakers[receiver.Path], key)
+		}
+	}
+}
+
+func (c *circuitBreakers) delete(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, breaker := range c.breakers[path] {
+		breaker.remove()
+	}
+
+	delete(c.breakers, path)
+}
+
+func (r Receiver) hasCircuitBreaker(key circuitKey) bool {
+	for _, target := range r.Targets {
+		if target.CircuitBreaker != nil && target.Address == key.address && target.Port == key.port {
+			return true
+		}
+	}
+
+	return false
+}
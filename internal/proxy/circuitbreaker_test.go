@@ -0,0 +1,327 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	type delivery struct {
+		outcome       outcome
+		advance       time.Duration
+		expectAllowed bool
+		expectState   CircuitState
+	}
+
+	tests := []struct {
+		name       string
+		policy     CircuitBreakerPolicy
+		deliveries []delivery
+	}{
+		{
+			name:   "Opens after consecutive failures",
+			policy: CircuitBreakerPolicy{ConsecutiveFailures: 2},
+			deliveries: []delivery{
+				{outcome: outcomeFailure, expectAllowed: true, expectState: CircuitClosed},
+				{outcome: outcomeSuccess, expectAllowed: true, expectState: CircuitClosed},
+				{outcome: outcomeFailure, expectAllowed: true, expectState: CircuitClosed},
+				{outcome: outcomeFailure, expectAllowed: true, expectState: CircuitOpen},
+				{outcome: outcomeSuccess, expectAllowed: false, expectState: CircuitOpen},
+			},
+		},
+		{
+			name:   "Opens once the failure percentage is reached",
+			policy: CircuitBreakerPolicy{FailurePercentage: 50, MinimumDeliveries: 4},
+			deliveries: []delivery{
+				{outcome: outcomeFailure, expectAllowed: true, expectState: CircuitClosed},
+				{outcome: outcomeSuccess, expectAllowed: true, expectState: CircuitClosed},
+				{outcome: outcomeFailure, expectAllowed: true, expectState: CircuitClosed},
+				{outcome: outcomeSuccess, expectAllowed: true, expectState: CircuitClosed},
+				{outcome: outcomeFailure, expectAllowed: true, expectState: CircuitOpen},
+			},
+		},
+		{
+			name:   "Failure percentage is calculated over the window",
+			policy: CircuitBreakerPolicy{FailurePercentage: 50, MinimumDeliveries: 2, Window: time.Minute},
+			deliveries: []delivery{
+				{outcome: outcomeSuccess, expectAllowed: true, expectState: CircuitClosed},
+				{outcome: outcomeSuccess, expectAllowed: true, expectState: CircuitClosed},
+				{outcome: outcomeFailure, advance: time.Minute, expectAllowed: true, expectState: CircuitClosed},
+				{outcome: outcomeFailure, expectAllowed: true, expectState: CircuitOpen},
+			},
+		},
+		{
+			name:   "Closes after successful probes",
+			policy: CircuitBreakerPolicy{ConsecutiveFailures: 1, OpenDuration: time.Second, HalfOpenProbes: 2},
+			deliveries: []delivery{
+				{outcome: outcomeFailure, expectAllowed: true, expectState: CircuitOpen},
+				{outcome: outcomeSuccess, advance: time.Second, expectAllowed: true, expectState: CircuitHalfOpen},
+				{outcome: outcomeSuccess, expectAllowed: true, expectState: CircuitClosed},
+				{outcome: outcomeSuccess, expectAllowed: true, expectState: CircuitClosed},
+			},
+		},
+		{
+			name:   "Opens again after a failed probe",
+			policy: CircuitBreakerPolicy{ConsecutiveFailures: 1, OpenDuration: time.Second},
+			deliveries: []delivery{
+				{outcome: outcomeFailure, expectAllowed: true, expectState: CircuitOpen},
+				{outcome: outcomeFailure, advance: time.Second, expectAllowed: true, expectState: CircuitOpen},
+				{outcome: outcomeSuccess, expectAllowed: false, expectState: CircuitOpen},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			now := time.Now()
+			breaker := newCircuitBreaker(test.policy, func(CircuitState) {})
+			breaker.nowFunc = func() time.Time { return now }
+
+			for i, delivery := range test.deliveries {
+				now = now.Add(delivery.advance)
+
+				allowed, probe := breaker.allow()
+				g.Expect(allowed).To(Equal(delivery.expectAllowed), "delivery %d", i)
+				if allowed {
+					breaker.record(delivery.outcome, probe)
+				}
+
+				g.Expect(breaker.getState()).To(Equal(delivery.expectState), "delivery %d", i)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHalfOpenLimitsProbes(t *testing.T) {
+	g := NewWithT(t)
+	now := time.Now()
+	breaker := newCircuitBreaker(CircuitBreakerPolicy{ConsecutiveFailures: 1, OpenDuration: time.Second}, func(CircuitState) {})
+	breaker.nowFunc = func() time.Time { return now }
+
+	breaker.record(outcomeFailure, false)
+	now = now.Add(time.Second)
+
+	allowed, probe := breaker.allow()
+	g.Expect(allowed).To(BeTrue())
+	g.Expect(probe).To(BeTrue())
+
+	allowed, _ = breaker.allow()
+	g.Expect(allowed).To(BeFalse(), "only one probe is sent while half open")
+
+	// a delivery which started before the circuit opened does not close it
+	breaker.record(outcomeSuccess, false)
+	g.Expect(breaker.getState()).To(Equal(CircuitHalfOpen))
+}
+
+func TestCircuitBreakerWait(t *testing.T) {
+	g := NewWithT(t)
+	breaker := newCircuitBreaker(CircuitBreakerPolicy{ConsecutiveFailures: 1, OpenDuration: 50 * time.Millisecond}, func(CircuitState) {})
+	breaker.record(outcomeFailure, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err := breaker.wait(ctx)
+	g.Expect(err).To(MatchError(context.DeadlineExceeded))
+
+	probe, err := breaker.wait(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(probe).To(BeTrue())
+}
+
+func TestServeHTTP_CircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name              string
+		action            CircuitOpenAction
+		expectedRequests  int64
+		expectDeadLetters int
+	}{
+		{
+			name:             "Skips deliveries while the circuit is open",
+			action:           CircuitSkip,
+			expectedRequests: 1,
+		},
+		{
+			name:              "Keeps dead letters while the circuit is open",
+			action:            CircuitDeadLetter,
+			expectedRequests:  1,
+			expectDeadLetters: 1,
+		},
+		{
+			name:             "Queues deliveries until the circuit allows them",
+			action:           CircuitQueue,
+			expectedRequests: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			var requests atomic.Int64
+
+			opts := DefaultOptions
+			opts.Client = &http.Client{
+				Transport: &dummyTransport{
+					transport: func(r *http.Request) (*http.Response, error) {
+						if requests.Add(1) == 1 {
+							return nil, fmt.Errorf("connection refused")
+						}
+
+						return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+					},
+				},
+			}
+
+			proxy := New(opts)
+			receiver := Receiver{
+				Path:         "/hook",
+				ResponseType: AwaitAllPreferFailed,
+				Targets: []Target{
+					{
+						Address:     "target",
+						Port:        8080,
+						ServiceName: "service",
+						CircuitBreaker: &CircuitBreakerPolicy{
+							ConsecutiveFailures: 1,
+							OpenDuration:        50 * time.Millisecond,
+							OpenAction:          test.action,
+						},
+					},
+				},
+			}
+
+			g.Expect(proxy.RegisterOrUpdate(receiver)).To(Succeed())
+
+			for _, expectedCode := range []int{http.StatusGatewayTimeout, http.StatusServiceUnavailable} {
+				req, _ := http.NewRequest("POST", "http://example.com/hook", strings.NewReader("body"))
+				w := httptest.NewRecorder()
+				proxy.ServeHTTP(w, req)
+				g.Expect(w.Code).To(Equal(expectedCode))
+			}
+
+			g.Expect(proxy.breakers.state("/hook", receiver.Targets[0])).To(Equal(CircuitOpen))
+			proxy.Close()
+
+			g.Expect(requests.Load()).To(Equal(test.expectedRequests))
+
+			req := httptest.NewRequest("GET", "/admin/deadletters", nil)
+			w := httptest.NewRecorder()
+			proxy.AdminHandler().ServeHTTP(w, req)
+
+			var deadLetters []DeadLetter
+			g.Expect(json.Unmarshal(w.Body.Bytes(), &deadLetters)).To(Succeed())
+			g.Expect(deadLetters).To(HaveLen(test.expectDeadLetters))
+			for _, deadLetter := range deadLetters {
+				g.Expect(deadLetter.Method).To(Equal("POST"))
+				g.Expect(string(deadLetter.Body)).To(Equal("body"))
+			}
+		})
+	}
+}
+
+func TestCircuitBreakers_RemovesStateSeries(t *testing.T) {
+	g := NewWithT(t)
+	registry := prometheus.NewPedanticRegistry()
+	g.Expect(registry.Register(targetCircuitState)).To(Succeed())
+
+	series := func(service string) int {
+		families, err := registry.Gather()
+		g.Expect(err).NotTo(HaveOccurred())
+
+		var count int
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if label.GetName() == "service" && label.GetValue() == service {
+						count++
+					}
+				}
+			}
+		}
+
+		return count
+	}
+
+	policy := &CircuitBreakerPolicy{ConsecutiveFailures: 1}
+	targetA := Target{Address: "target-a", Port: 8080, ServiceName: "pruned-a", CircuitBreaker: policy}
+	targetB := Target{Address: "target-b", Port: 8080, ServiceName: "pruned-b", CircuitBreaker: policy}
+	receiver := Receiver{Path: "/hook", Targets: []Target{targetA, targetB}}
+
+	proxy := New(DefaultOptions)
+	g.Expect(proxy.RegisterOrUpdate(receiver)).To(Succeed())
+	proxy.breakers.get(receiver, targetA)
+	proxy.breakers.get(receiver, targetB)
+
+	g.Expect(series("pruned-a")).To(Equal(3))
+	g.Expect(series("pruned-b")).To(Equal(3))
+
+	receiver.Targets = []Target{targetB}
+	g.Expect(proxy.RegisterOrUpdate(receiver)).To(Succeed())
+	g.Expect(series("pruned-a")).To(Equal(0), "series of pruned targets are deleted")
+	g.Expect(series("pruned-b")).To(Equal(3))
+
+	g.Expect(proxy.Unregister("/hook")).To(Succeed())
+	g.Expect(series("pruned-b")).To(Equal(0), "series of deleted receivers are deleted")
+}
+
+func TestServeHTTP_CircuitQueueLimit(t *testing.T) {
+	g := NewWithT(t)
+
+	opts := DefaultOptions
+	opts.Client = &http.Client{
+		Transport: &dummyTransport{
+			transport: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: 500, Body: io.NopCloser(strings.NewReader("failed"))}, nil
+			},
+		},
+	}
+
+	proxy := New(opts)
+	g.Expect(proxy.RegisterOrUpdate(Receiver{
+		Path:         "/hook",
+		ResponseType: AwaitAllPreferFailed,
+		Targets: []Target{
+			{
+				Address:     "target",
+				Port:        8080,
+				ServiceName: "queue-limit",
+				CircuitBreaker: &CircuitBreakerPolicy{
+					ConsecutiveFailures: 1,
+					OpenDuration:        time.Hour,
+					OpenAction:          CircuitQueue,
+					QueueTimeout:        time.Hour,
+					QueueLimit:          1,
+				},
+			},
+		},
+	})).To(Succeed())
+
+	for range 3 {
+		req, _ := http.NewRequest("POST", "http://example.com/hook", strings.NewReader("body"))
+		proxy.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	g.Expect(testutil.ToFloat64(targetCircuitQueueDepth.WithLabelValues("", "", "queue-limit", ""))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(targetCircuitOpenTotal.WithLabelValues("", "", "queue-limit", "", string(CircuitQueue)))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(targetCircuitOpenTotal.WithLabelValues("", "", "queue-limit", "", string(CircuitDeadLetter)))).To(Equal(1.0), "deliveries exceeding the queue limit are dead-lettered")
+	g.Expect(proxy.deadLetters.list()).To(HaveLen(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	g.Expect(proxy.Drain(ctx)).To(Succeed(), "queued deliveries do not block the shutdown")
+	g.Expect(testutil.ToFloat64(targetCircuitQueueDepth.WithLabelValues("", "", "queue-limit", ""))).To(BeZero())
+	g.Expect(testutil.ToFloat64(targetDroppedTotal.WithLabelValues("", "", "queue-limit", "", "circuit"))).To(Equal(1.0))
+}
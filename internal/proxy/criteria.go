@@ -0,0 +1,213 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+)
+
+// StatusCodeRange is an inclusive range of http status codes
+type StatusCodeRange struct {
+	From int
+	To   int
+}
+
+var (
+	DefaultSuccessCodes = []StatusCodeRange{{From: 200, To: 299}}
+	DefaultFailureCodes = []StatusCodeRange{{From: 400, To: 599}}
+)
+
+// ParseStatusCodeRange parses a single status code like `409` or a range like `200-299`
+func ParseStatusCodeRange(s string) (StatusCodeRange, error) {
+	from, to, isRange := strings.Cut(s, "-")
+
+	start, err := strconv.Atoi(from)
+	if err != nil {
+		return StatusCodeRange{}, fmt.Errorf("invalid status code range %q: %w", s, err)
+	}
+
+	end := start
+	if isRange {
+		end, err = strconv.Atoi(to)
+		if err != nil {
+			return StatusCodeRange{}, fmt.Errorf("invalid status code range %q: %w", s, err)
+		}
+	}
+
+	if start < 100 || end > 599 || start > end {
+		return StatusCodeRange{}, fmt.Errorf("invalid status code range %q", s)
+	}
+
+	return StatusCodeRange{From: start, To: end}, nil
+}
+
+func (r StatusCodeRange) contains(code int) bool {
+	return code >= r.From && code <= r.To
+}
+
+func matchesAny(ranges []StatusCodeRange, code int) bool {
+	for _, r := range ranges {
+		if r.contains(code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+const (
+	// expressionCostLimit bounds the cost of a single evaluation of a success expression
+	expressionCostLimit = 1000000
+
+	// expressionInterruptCheckFrequency is the number of comprehension iterations after which the context is checked
+	expressionInterruptCheckFrequency = 100
+
+	// ExpressionBodySizeLimit is the number of response body bytes a success expression reads
+	ExpressionBodySizeLimit = 1 << 20
+)
+
+// Expression is a compiled CEL expression which is evaluated against a target response
+type Expression struct {
+	source  string
+	program cel.Program
+}
+
+// CompileExpression compiles a CEL expression which must evaluate to a bool.
+// The expression has access to the variables statusCode, headers and body.
+// body is the decoded json response body or the raw body as string if it is not json.
+// The cost of an evaluation is limited and it is interrupted once the context of the request is done.
+func CompileExpression(expr string) (*Expression, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("statusCode", cel.IntType),
+		cel.Variable("headers", cel.MapType(cel.StringType, cel.ListType(cel.StringType))),
+		cel.Variable("body", cel.DynType),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("expression %q must evaluate to bool, got %s", expr, ast.OutputType())
+	}
+
+	program, err := env.Program(ast,
+		cel.CostLimit(expressionCostLimit),
+		cel.InterruptCheckFrequency(expressionInterruptCheckFrequency),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Expression{source: expr, program: program}, nil
+}
+
+// String returns the source of the expression
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression against a response.
+// At most ExpressionBodySizeLimit bytes of the response body are read, the expression fails if the body is larger.
+// The response body is replaced with a reader returning the read bytes followed by the remaining body.
+func (e *Expression) Eval(ctx context.Context, response *http.Response) (bool, error) {
+	var raw []byte
+	if response.Body != nil {
+		body := response.Body
+		b, err := io.ReadAll(io.LimitReader(body, ExpressionBodySizeLimit+1))
+		response.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(b), body), body}
+
+		if err != nil {
+			return false, err
+		}
+
+		if len(b) > ExpressionBodySizeLimit {
+			return false, fmt.Errorf("response body exceeds the limit of %d bytes", ExpressionBodySizeLimit)
+		}
+
+		raw = b
+	}
+
+	var body any = string(raw)
+	var decoded any
+	if json.Unmarshal(raw, &decoded) == nil {
+		body = decoded
+	}
+
+	headers := map[string][]string(response.Header)
+	if headers == nil {
+		headers = map[string][]string{}
+	}
+
+	out, _, err := e.program.ContextEval(ctx, map[string]any{
+		"statusCode": response.StatusCode,
+		"headers":    headers,
+		"body":       body,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	result, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %q did not evaluate to bool", e.source)
+	}
+
+	return result, nil
+}
+
+// outcome classifies a target response
+type outcome string
+
+const (
+	outcomeSuccess outcome = "success"
+	outcomeFailure outcome = "failure"
+	outcomeOther   outcome = "other"
+)
+
+// classify decides whether a response is successful or failed according to the targets success criteria.
+// A status code matching the success codes takes precedence over the failure codes.
+// If the target has a success expression a response with a success code only succeeds if the expression evaluates to true.
+func (t Target) classify(ctx context.Context, response *http.Response) (outcome, error) {
+	successCodes := t.SuccessCodes
+	if len(successCodes) == 0 {
+		successCodes = DefaultSuccessCodes
+	}
+
+	failureCodes := t.FailureCodes
+	if len(failureCodes) == 0 {
+		failureCodes = DefaultFailureCodes
+	}
+
+	switch {
+	case matchesAny(successCodes, response.StatusCode):
+		if t.SuccessExpression == nil {
+			return outcomeSuccess, nil
+		}
+
+		ok, err := t.SuccessExpression.Eval(ctx, response)
+		if err != nil || !ok {
+			return outcomeFailure, err
+		}
+
+		return outcomeSuccess, nil
+	case matchesAny(failureCodes, response.StatusCode):
+		return outcomeFailure, nil
+	default:
+		return outcomeOther, nil
+	}
+}
@@ -0,0 +1,215 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseStatusCodeRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected StatusCodeRange
+		err      bool
+	}{
+		{input: "409", expected: StatusCodeRange{From: 409, To: 409}},
+		{input: "200-299", expected: StatusCodeRange{From: 200, To: 299}},
+		{input: "299-200", err: true},
+		{input: "600", err: true},
+		{input: "2xx", err: true},
+		{input: "200-", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			g := NewWithT(t)
+			r, err := ParseStatusCodeRange(test.input)
+			if test.err {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(r).To(Equal(test.expected))
+		})
+	}
+}
+
+func TestCompileExpression(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := CompileExpression("statusCode")
+	g.Expect(err).To(HaveOccurred(), "non bool expressions must be rejected")
+
+	_, err = CompileExpression("body.")
+	g.Expect(err).To(HaveOccurred())
+
+	_, err = CompileExpression(`statusCode == 200 && body.status == "ok"`)
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestTargetClassify(t *testing.T) {
+	mustCompile := func(expr string) *Expression {
+		e, err := CompileExpression(expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+
+	tests := []struct {
+		name       string
+		target     Target
+		statusCode int
+		body       string
+		expected   outcome
+	}{
+		{
+			name:       "2xx is successful by default",
+			statusCode: 202,
+			expected:   outcomeSuccess,
+		},
+		{
+			name:       "5xx is failed by default",
+			statusCode: 503,
+			expected:   outcomeFailure,
+		},
+		{
+			name:       "3xx is neither successful nor failed by default",
+			statusCode: 302,
+			expected:   outcomeOther,
+		},
+		{
+			name: "success codes take precedence over failure codes",
+			target: Target{
+				SuccessCodes: []StatusCodeRange{{From: 200, To: 299}, {From: 409, To: 409}},
+			},
+			statusCode: 409,
+			expected:   outcomeSuccess,
+		},
+		{
+			name: "custom failure codes",
+			target: Target{
+				FailureCodes: []StatusCodeRange{{From: 500, To: 599}},
+			},
+			statusCode: 404,
+			expected:   outcomeOther,
+		},
+		{
+			name: "expression evaluated against json body",
+			target: Target{
+				SuccessExpression: mustCompile(`body.status == "ok"`),
+			},
+			statusCode: 200,
+			body:       `{"status":"ok"}`,
+			expected:   outcomeSuccess,
+		},
+		{
+			name: "expression evaluating to false fails the response",
+			target: Target{
+				SuccessExpression: mustCompile(`body.status == "ok"`),
+			},
+			statusCode: 200,
+			body:       `{"status":"error"}`,
+			expected:   outcomeFailure,
+		},
+		{
+			name: "expression evaluated against raw body",
+			target: Target{
+				SuccessExpression: mustCompile(`body.contains("accepted")`),
+			},
+			statusCode: 200,
+			body:       "request accepted",
+			expected:   outcomeSuccess,
+		},
+		{
+			name: "expression fails if the body exceeds the limit",
+			target: Target{
+				SuccessExpression: mustCompile(`body.status == "ok"`),
+			},
+			statusCode: 200,
+			body:       `{"status":"ok","padding":"` + strings.Repeat("x", ExpressionBodySizeLimit) + `"}`,
+			expected:   outcomeFailure,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			response := &http.Response{
+				StatusCode: test.statusCode,
+				Body:       io.NopCloser(strings.NewReader(test.body)),
+			}
+
+			outcome, _ := test.target.classify(context.Background(), response)
+			g.Expect(outcome).To(Equal(test.expected))
+
+			body, err := io.ReadAll(response.Body)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(string(body)).To(Equal(test.body), "body must still be readable after classification")
+		})
+	}
+}
+
+func TestExpressionEvalBounds(t *testing.T) {
+	items := make([]string, 1000)
+	for i := range items {
+		items[i] = fmt.Sprint(i)
+	}
+
+	body := "[" + strings.Join(items, ",") + "]"
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name          string
+		expr          string
+		ctx           context.Context
+		expectedError string
+	}{
+		{
+			name: "evaluates the expression within the limits",
+			expr: `body.all(x, x >= 0)`,
+			ctx:  context.Background(),
+		},
+		{
+			name:          "aborts the evaluation once the cost limit is exceeded",
+			expr:          `body.all(x, body.all(y, x >= 0 && y >= 0))`,
+			ctx:           context.Background(),
+			expectedError: "cost limit exceeded",
+		},
+		{
+			name:          "interrupts the evaluation once the context is done",
+			expr:          `body.all(x, x >= 0)`,
+			ctx:           canceled,
+			expectedError: "interrupted",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			expr, err := CompileExpression(test.expr)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			ok, err := expr.Eval(test.ctx, &http.Response{
+				StatusCode: 200,
+				Body:       io.NopCloser(strings.NewReader(body)),
+			})
+
+			if test.expectedError != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(test.expectedError)))
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ok).To(BeTrue())
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DebounceKeep decides which request of a debounce window is delivered
+type DebounceKeep string
+
+const (
+	// DebounceFirst delivers the first request right away and drops the following ones until the window ends
+	DebounceFirst DebounceKeep = "First"
+	// DebounceLast delivers the last request once the window ends
+	DebounceLast DebounceKeep = "Last"
+)
+
+// DebouncePolicy coalesces the requests with the same key within a window into one delivery.
+// The window starts with the first request, requests without a key share one window.
+type DebouncePolicy struct {
+	Window time.Duration
+	Key    RequestKey
+	Keep   DebounceKeep
+}
+
+type debounceWindow struct {
+	end    time.Time
+	latest uint64
+}
+
+// debouncer tracks the open debounce windows of all targets
+type debouncer struct {
+	mu      sync.Mutex
+	windows map[targetKey]*debounceWindow
+	seq     uint64
+}
+
+// debounce registers a request in the window of its key in the order requests are received.
+// The returned function reports whether the request is delivered or dropped in favor of another request of the same window,
+// for DebounceLast it blocks until the window ends.
+func (d *debouncer) debounce(key targetKey, policy DebouncePolicy) func(ctx context.Context) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.windows == nil {
+		d.windows = make(map[targetKey]*debounceWindow)
+	}
+
+	now := time.Now()
+	window, ok := d.windows[key]
+	switch {
+	case ok && now.Before(window.end) && policy.Keep == DebounceFirst:
+		return func(context.Context) bool { return false }
+	case !ok || !now.Before(window.end):
+		window = &debounceWindow{end: now.Add(policy.Window)}
+		d.windows[key] = window
+		time.AfterFunc(policy.Window, func() {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			if d.windows[key] == window {
+				delete(d.windows, key)
+			}
+		})
+
+		if policy.Keep == DebounceFirst {
+			return func(context.Context) bool { return true }
+		}
+	}
+
+	d.seq++
+	seq := d.seq
+	window.latest = seq
+
+	return func(ctx context.Context) bool {
+		if !sleep(ctx, time.Until(window.end)) {
+			return false
+		}
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return window.latest == seq
+	}
+}
@@ -0,0 +1,168 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestServeHTTP_Debounce(t *testing.T) {
+	tests := []struct {
+		name         string
+		keep         DebounceKeep
+		requests     []string
+		expectedBody []string
+	}{
+		{
+			name:         "Delivers the last request of a window",
+			keep:         DebounceLast,
+			requests:     []string{`{"repo":"a","seq":1}`, `{"repo":"a","seq":2}`, `{"repo":"a","seq":3}`},
+			expectedBody: []string{`{"repo":"a","seq":3}`},
+		},
+		{
+			name:         "Delivers the first request of a window",
+			keep:         DebounceFirst,
+			requests:     []string{`{"repo":"a","seq":1}`, `{"repo":"a","seq":2}`, `{"repo":"a","seq":3}`},
+			expectedBody: []string{`{"repo":"a","seq":1}`},
+		},
+		{
+			name:         "Requests with different keys are delivered in their own window",
+			keep:         DebounceLast,
+			requests:     []string{`{"repo":"a","seq":1}`, `{"repo":"b","seq":2}`, `{"repo":"a","seq":3}`},
+			expectedBody: []string{`{"repo":"b","seq":2}`, `{"repo":"a","seq":3}`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			var mu sync.Mutex
+			var received []string
+
+			opts := DefaultOptions
+			opts.Client = &http.Client{
+				Transport: &dummyTransport{
+					transport: func(r *http.Request) (*http.Response, error) {
+						b, _ := io.ReadAll(r.Body)
+						mu.Lock()
+						received = append(received, string(b))
+						mu.Unlock()
+
+						return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+					},
+				},
+			}
+
+			proxy := New(opts)
+			g.Expect(proxy.RegisterOrUpdate(Receiver{
+				Path:         "/hook",
+				ResponseType: AwaitAllPreferSuccessful,
+				Targets: []Target{
+					{
+						Address: "target",
+						Port:    8080,
+						Debounce: &DebouncePolicy{
+							Window: 100 * time.Millisecond,
+							Key:    RequestKey{BodyField: "repo"},
+							Keep:   test.keep,
+						},
+					},
+				},
+			})).To(Succeed())
+
+			for _, body := range test.requests {
+				req, _ := http.NewRequest("POST", "http://example.com/hook", strings.NewReader(body))
+				w := httptest.NewRecorder()
+				proxy.ServeHTTP(w, req)
+				g.Expect(w.Code).To(Equal(http.StatusAccepted), "debounced targets are not awaited")
+			}
+
+			proxy.Close()
+			g.Expect(received).To(ConsistOf(test.expectedBody))
+		})
+	}
+}
+
+func TestServeHTTP_Delay(t *testing.T) {
+	g := NewWithT(t)
+	var mu sync.Mutex
+	var receivedAt time.Time
+
+	opts := DefaultOptions
+	opts.Client = &http.Client{
+		Transport: &dummyTransport{
+			transport: func(r *http.Request) (*http.Response, error) {
+				mu.Lock()
+				receivedAt = time.Now()
+				mu.Unlock()
+
+				return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+			},
+		},
+	}
+
+	proxy := New(opts)
+	g.Expect(proxy.RegisterOrUpdate(Receiver{
+		Path:         "/hook",
+		ResponseType: FirstSuccessful,
+		Targets: []Target{
+			{Address: "target", Port: 8080, Delay: 100 * time.Millisecond},
+		},
+	})).To(Succeed())
+
+	start := time.Now()
+	req, _ := http.NewRequest("POST", "http://example.com/hook", strings.NewReader("body"))
+	w := httptest.NewRecorder()
+	proxy.ServeHTTP(w, req)
+	g.Expect(w.Code).To(Equal(http.StatusAccepted), "delayed targets are not awaited")
+
+	proxy.Close()
+	g.Expect(receivedAt.Sub(start)).To(BeNumerically(">=", 100*time.Millisecond))
+}
+
+func TestDrain_DropsWaitingDeliveries(t *testing.T) {
+	g := NewWithT(t)
+	var received atomic.Int32
+
+	opts := DefaultOptions
+	opts.Client = &http.Client{
+		Transport: &dummyTransport{
+			transport: func(r *http.Request) (*http.Response, error) {
+				received.Add(1)
+				return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+			},
+		},
+	}
+
+	proxy := New(opts)
+	g.Expect(proxy.RegisterOrUpdate(Receiver{
+		Path:         "/hook",
+		ResponseType: Async,
+		Targets: []Target{
+			{Address: "delayed", Port: 8080, ServiceName: "drain-delayed", Delay: time.Hour},
+			{Address: "debounced", Port: 8080, ServiceName: "drain-debounced", Debounce: &DebouncePolicy{Window: time.Hour, Keep: DebounceLast}},
+		},
+	})).To(Succeed())
+
+	req, _ := http.NewRequest("POST", "http://example.com/hook", strings.NewReader("body"))
+	w := httptest.NewRecorder()
+	proxy.ServeHTTP(w, req)
+	g.Expect(w.Code).To(Equal(http.StatusAccepted))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	g.Expect(proxy.Drain(ctx)).To(Succeed(), "waiting deliveries do not block the shutdown")
+	g.Expect(received.Load()).To(BeZero())
+	g.Expect(testutil.ToFloat64(targetDroppedTotal.WithLabelValues("", "", "drain-delayed", "", "delay"))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(targetDroppedTotal.WithLabelValues("", "", "drain-debounced", "", "debounce"))).To(Equal(1.0))
+}
@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// DefaultFailureEventInterval is the interval in which failed deliveries are aggregated into a single event per target
+const DefaultFailureEventInterval = 5 * time.Minute
+
+// DeliveryFailedReason is the reason of the events recorded for failed deliveries
+const DeliveryFailedReason = "DeliveryFailed"
+
+type failureKey struct {
+	owner     types.UID
+	service   string
+	namespace string
+	port      int32
+}
+
+type failureCount struct {
+	object *corev1.ObjectReference
+	count  int
+	last   string
+}
+
+// failureEvents aggregates failed deliveries per target until they are flushed as events
+type failureEvents struct {
+	mu     sync.Mutex
+	counts map[failureKey]*failureCount
+}
+
+func (e *failureEvents) add(receiver Receiver, result targetResult) {
+	if receiver.Object == nil {
+		return
+	}
+
+	last := strconv.Itoa(result.response.StatusCode)
+	if result.err != nil {
+		last = result.err.Error()
+	}
+
+	key := failureKey{
+		owner:     receiver.Object.UID,
+		service:   result.target.ServiceName,
+		namespace: result.target.ServiceNamespace,
+		port:      result.target.Port,
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.counts == nil {
+		e.counts = make(map[failureKey]*failureCount)
+	}
+
+	failures, ok := e.counts[key]
+	if !ok {
+		failures = &failureCount{}
+		e.counts[key] = failures
+	}
+
+	failures.object = receiver.Object
+	failures.count++
+	failures.last = last
+}
+
+// flushFailureEvents records one warning event per target which failed since the last flush
+func (h *HttpProxy) flushFailureEvents() {
+	h.failureEvents.mu.Lock()
+	counts := h.failureEvents.counts
+	h.failureEvents.counts = nil
+	h.failureEvents.mu.Unlock()
+
+	if h.recorder == nil {
+		return
+	}
+
+	for key, failures := range counts {
+		h.recorder.Eventf(failures.object, "Warning", DeliveryFailedReason, "target %s/%s:%d failed %d times in last %s: %s",
+			key.namespace, key.service, key.port, failures.count, duration.HumanDuration(h.failureEventInterval), failures.last)
+	}
+}
+
+// Start records the aggregated delivery failures as events on the owning resources once per interval until the context is done
+func (h *HttpProxy) Start(ctx context.Context) error {
+	ticker := time.NewTicker(h.failureEventInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.flushFailureEvents()
+			return nil
+		case <-ticker.C:
+			h.flushFailureEvents()
+		}
+	}
+}
+
+// NeedLeaderElection returns false since every replica delivers webhooks
+func (h *HttpProxy) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestFailureEvents(t *testing.T) {
+	g := NewWithT(t)
+	recorder := record.NewFakeRecorder(10)
+
+	opts := DefaultOptions
+	opts.Recorder = recorder
+	opts.Client = &http.Client{
+		Transport: &dummyTransport{
+			transport: func(r *http.Request) (*http.Response, error) {
+				statusCode := http.StatusOK
+				if r.URL.Host == "failing:8080" {
+					statusCode = http.StatusServiceUnavailable
+				}
+
+				return &http.Response{StatusCode: statusCode, Body: io.NopCloser(strings.NewReader("body"))}, nil
+			},
+		},
+	}
+
+	proxy := New(opts)
+	g.Expect(proxy.RegisterOrUpdate(Receiver{
+		Path:         "/hook",
+		ResponseType: AwaitAllPreferSuccessful,
+		Object:       &corev1.ObjectReference{Namespace: "default", Name: "receiver", UID: "1234"},
+		Targets: []Target{
+			{Address: "failing", Port: 8080, ServiceName: "failing", ServiceNamespace: "default"},
+			{Address: "healthy", Port: 8080, ServiceName: "healthy", ServiceNamespace: "default"},
+		},
+	})).To(Succeed())
+
+	for range 3 {
+		w := httptest.NewRecorder()
+		proxy.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader("body")))
+		g.Expect(w.Code).To(Equal(http.StatusOK))
+	}
+
+	g.Expect(recorder.Events).To(BeEmpty(), "failures are aggregated until flushed")
+
+	proxy.flushFailureEvents()
+	g.Expect(recorder.Events).To(Receive(Equal("Warning DeliveryFailed target default/failing:8080 failed 3 times in last 5m: 503")))
+	g.Expect(recorder.Events).To(BeEmpty(), "only failed targets are reported")
+
+	proxy.flushFailureEvents()
+	g.Expect(recorder.Events).To(BeEmpty(), "no event without new failures")
+}
+
+func TestFailureEvents_Start(t *testing.T) {
+	g := NewWithT(t)
+	recorder := record.NewFakeRecorder(10)
+
+	opts := DefaultOptions
+	opts.Recorder = recorder
+	opts.FailureEventInterval = time.Second
+	proxy := New(opts)
+
+	g.Expect(proxy.NeedLeaderElection()).To(BeFalse())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- proxy.Start(ctx)
+	}()
+
+	proxy.failureEvents.add(Receiver{
+		Object: &corev1.ObjectReference{Namespace: "default", Name: "receiver", UID: "1234"},
+	}, targetResult{
+		target:   Target{ServiceName: "podinfo", ServiceNamespace: "default", Port: 9898},
+		response: &http.Response{StatusCode: http.StatusGatewayTimeout},
+		err:      context.DeadlineExceeded,
+	})
+
+	g.Eventually(recorder.Events, 3*time.Second).Should(Receive(Equal("Warning DeliveryFailed target default/podinfo:9898 failed 1 times in last 1s: context deadline exceeded")))
+
+	cancel()
+	g.Eventually(stopped).Should(Receive(BeNil()))
+}
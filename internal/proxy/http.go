@@ -2,22 +2,34 @@ package proxy
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 var (
 	ErrServiceNotRegistered = errors.New("service is not registered")
 )
 
+// BodyTruncatedHeader is set on forwarded requests if the body has been truncated to the body size limit
+const BodyTruncatedHeader = "X-Webhook-Body-Truncated"
+
+// DefaultStreamBufferSize is the number of bytes buffered in memory per target for streamed bodies
+const DefaultStreamBufferSize = 1 << 20
+
 type ResponseType string
 
 const (
@@ -25,6 +37,9 @@ const (
 	AwaitAllPreferSuccessful ResponseType = "AwaitAllPreferSuccessful"
 	AwaitAllPreferFailed     ResponseType = "AwaitAllPreferFailed"
 	AwaitAllReport           ResponseType = "AwaitAllReport"
+	FirstSuccessful          ResponseType = "FirstSuccessful"
+	Quorum                   ResponseType = "Quorum"
+	Primary                  ResponseType = "Primary"
 )
 
 type Target struct {
@@ -33,8 +48,35 @@ type Target struct {
 	Port             int32
 	ServiceName      string
 	ServiceNamespace string
-	ResponseType     ResponseType
-	BodySizeLimit    int64
+	Primary          bool
+
+	// Timeout overrides the timeout of the receiver for this target
+	Timeout time.Duration
+	// BodySizeLimit truncates the body forwarded to this target, 0 means the receiver body is forwarded as is
+	BodySizeLimit int64
+	// FireAndForget targets are never awaited, they are sent like mirrors regardless of the response type
+	FireAndForget bool
+	// CircuitBreaker stops deliveries to the target while it keeps failing, nil disables the circuit breaker
+	CircuitBreaker *CircuitBreakerPolicy
+	// Delay postpones deliveries to the target
+	Delay time.Duration
+	// Debounce coalesces requests to the target, nil disables debouncing
+	Debounce *DebouncePolicy
+	// Sampling forwards only a subset of the requests to the target, nil forwards all requests
+	Sampling *SamplingPolicy
+
+	// SuccessCodes and FailureCodes classify target responses, see DefaultSuccessCodes and DefaultFailureCodes
+	SuccessCodes      []StatusCodeRange
+	FailureCodes      []StatusCodeRange
+	SuccessExpression *Expression
+	Retry             RetryPolicy
+}
+
+// RetryPolicy defines how failed target requests are retried
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts including the first one
+	Attempts int
+	Interval time.Duration
 }
 
 type Receiver struct {
@@ -43,19 +85,111 @@ type Receiver struct {
 	Targets       []Target
 	ResponseType  ResponseType
 	BodySizeLimit int64
+	Quorum        int
+	Report        ReportOptions
+
+	// BodySizeLimitAction decides what happens with requests exceeding the body size limit, defaults to Truncate
+	BodySizeLimitAction BodySizeLimitAction
+
+	// Object is a reference to the kubernetes resource which registered the receiver.
+	// It identifies the receiver in logs, metrics and traces and events are recorded on it.
+	Object *corev1.ObjectReference
+	// Generation is the generation of Object which has been registered
+	Generation int64
+
+	// Streaming tees the incoming body to all targets while it is received instead of buffering it.
+	// Up to StreamBufferSize bytes are buffered in memory per target, the rest is spilled to disk.
+	Streaming        bool
+	StreamBufferSize int64
+
+	// OrderingKey serializes the deliveries of requests with the same key to a target, nil delivers all requests concurrently
+	OrderingKey *RequestKey
+}
+
+type BodySizeLimitAction string
+
+const (
+	// Reject responds with 413 Payload Too Large
+	Reject BodySizeLimitAction = "Reject"
+	// Truncate forwards the body truncated to the limit and marks the request with BodyTruncatedHeader
+	Truncate BodySizeLimitAction = "Truncate"
+)
+
+// ReportStatusCode decides the status code of an AwaitAllReport response
+type ReportStatusCode string
+
+const (
+	// ReportOK always responds with 200 OK
+	ReportOK ReportStatusCode = "OK"
+	// ReportMultiStatus always responds with 207 Multi-Status
+	ReportMultiStatus ReportStatusCode = "MultiStatus"
+	// ReportBadGatewayOnFailure responds with 502 Bad Gateway if any target failed and 200 OK otherwise
+	ReportBadGatewayOnFailure ReportStatusCode = "BadGatewayOnFailure"
+)
+
+type ReportOptions struct {
+	StatusCode ReportStatusCode
+	// BodySizeLimit limits the size of each target response body included in the report, 0 means unlimited
+	BodySizeLimit int64
 }
 
 type HttpProxy struct {
-	receivers map[string]Receiver
+	// receivers is an immutable snapshot of the registered receivers which is replaced on every change.
+	// Lookups never lock while mutex serializes writers.
+	receivers atomic.Pointer[map[string]Receiver]
 	client    *http.Client
 	mutex     sync.Mutex
 	log       logr.Logger
 	wg        sync.WaitGroup
+	inFlight  atomic.Int64
+	spoolDir  string
+	recorder  record.EventRecorder
+
+	logRequestURI bool
+
+	// stopping is canceled once the proxy drains, deliveries which are still waiting to be sent are dropped
+	stopping context.Context
+	stop     context.CancelFunc
+
+	// pending counts the unfinished target deliveries per receiver path
+	pending  sync.Map
+	failures ringLog[DeliveryFailure]
+
+	failureEvents        failureEvents
+	failureEventInterval time.Duration
+
+	stats       deliveryStats
+	breakers    circuitBreakers
+	debouncer   debouncer
+	sequencer   sequencer
+	deadLetters ringLog[DeadLetter]
+
+	deadLetterBodySizeLimit int64
+	redactHeaders           []string
+
+	defaultBodySizeLimit int64
 }
 
 type Options struct {
 	Logger logr.Logger
 	Client *http.Client
+	// SpoolDir is the directory used to spill streamed request bodies, defaults to the os temp dir
+	SpoolDir string
+	// Recorder is used to record events on the resources owning a receiver
+	Recorder record.EventRecorder
+	// DefaultBodySizeLimit applies to receivers without a body size limit, 0 means unlimited
+	DefaultBodySizeLimit int64
+	// FailureEventInterval is the interval in which failed deliveries are aggregated into one event per target,
+	// defaults to DefaultFailureEventInterval. The events are recorded while the proxy is started.
+	FailureEventInterval time.Duration
+	// StatsWindow is the rolling window delivery counts are calculated over, defaults to DefaultStatsWindow
+	StatsWindow time.Duration
+	// LogRequestURI includes the request uri in logs, it is redacted by default since the webhook path is a secret
+	LogRequestURI bool
+	// DeadLetterBodySizeLimit is the number of body bytes kept per dead letter, defaults to DefaultDeadLetterBodySizeLimit
+	DeadLetterBodySizeLimit int64
+	// RedactHeaders are redacted in dead letters in addition to headers which look sensitive like Authorization or signatures
+	RedactHeaders []string
 }
 
 var DefaultOptions = Options{
@@ -64,26 +198,98 @@ var DefaultOptions = Options{
 }
 
 func New(opts Options) *HttpProxy {
-	return &HttpProxy{
-		log:       opts.Logger,
-		client:    opts.Client,
-		receivers: make(map[string]Receiver),
+	h := &HttpProxy{
+		log:      opts.Logger,
+		client:   opts.Client,
+		spoolDir: opts.SpoolDir,
+		recorder: opts.Recorder,
+
+		logRequestURI:        opts.LogRequestURI,
+		failureEventInterval: cmp.Or(opts.FailureEventInterval, DefaultFailureEventInterval),
+		stats: deliveryStats{
+			window: cmp.Or(opts.StatsWindow, DefaultStatsWindow),
+		},
+		defaultBodySizeLimit:    opts.DefaultBodySizeLimit,
+		deadLetterBodySizeLimit: cmp.Or(opts.DeadLetterBodySizeLimit, DefaultDeadLetterBodySizeLimit),
 	}
+
+	for _, header := range opts.RedactHeaders {
+		h.redactHeaders = append(h.redactHeaders, http.CanonicalHeaderKey(header))
+	}
+
+	h.stopping, h.stop = context.WithCancel(context.Background())
+	h.receivers.Store(&map[string]Receiver{})
+	return h
 }
 
 func (h *HttpProxy) Unregister(path string) error {
-	h.mutex.Lock()
-	defer h.mutex.Unlock()
+	h.update(func(receivers map[string]Receiver) {
+		delete(receivers, path)
+	})
+
+	h.pending.Delete(path)
+	h.breakers.delete(path)
 
-	delete(h.receivers, path)
 	return nil
 }
 
+// UnregisterOwner removes all receivers registered by the resource with the given namespace and name
+func (h *HttpProxy) UnregisterOwner(namespace, name string) error {
+	var removed []Receiver
+	h.update(func(receivers map[string]Receiver) {
+		for path, receiver := range receivers {
+			if receiver.ownedBy(namespace, name) {
+				delete(receivers, path)
+				removed = append(removed, receiver)
+			}
+		}
+	})
+
+	for _, receiver := range removed {
+		h.pending.Delete(receiver.Path)
+		h.breakers.delete(receiver.Path)
+		h.stats.delete(receiver.Object.UID)
+	}
+
+	return nil
+}
+
+// RegisterOrUpdate registers a receiver for its path.
+// The receiver must not be modified after it has been registered.
 func (h *HttpProxy) RegisterOrUpdate(receiver Receiver) error {
+	h.update(func(receivers map[string]Receiver) {
+		receivers[receiver.Path] = receiver
+	})
+
+	h.breakers.prune(receiver)
+
+	return nil
+}
+
+// update applies a change to a copy of the current snapshot and swaps it in
+func (h *HttpProxy) update(change func(receivers map[string]Receiver)) {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
-	h.receivers[receiver.Path] = receiver
-	return nil
+
+	receivers := maps.Clone(*h.receivers.Load())
+	change(receivers)
+	h.receivers.Store(&receivers)
+}
+
+// IsRegistered reports whether a receiver is registered for the path
+func (h *HttpProxy) IsRegistered(path string) bool {
+	_, ok := h.lookup(path)
+	return ok
+}
+
+// snapshot returns the currently registered receivers, it must not be modified
+func (h *HttpProxy) snapshot() map[string]Receiver {
+	return *h.receivers.Load()
+}
+
+func (h *HttpProxy) lookup(path string) (Receiver, bool) {
+	receiver, ok := h.snapshot()[path]
+	return receiver, ok
 }
 
 type ReportResponse struct {
@@ -91,162 +297,427 @@ type ReportResponse struct {
 }
 
 type ReportTargetResponse struct {
-	StatusCode int                 `json:"statusCode"`
-	Body       string              `json:"body,omitempty"`
-	Headers    map[string][]string `json:"headers,omitempty"`
+	Service       string              `json:"service,omitempty"`
+	Namespace     string              `json:"namespace,omitempty"`
+	Path          string              `json:"path,omitempty"`
+	StatusCode    int                 `json:"statusCode"`
+	Outcome       string              `json:"outcome"`
+	Attempts      int                 `json:"attempts"`
+	DurationMS    int64               `json:"durationMs"`
+	Error         string              `json:"error,omitempty"`
+	Body          string              `json:"body,omitempty"`
+	BodyTruncated bool                `json:"bodyTruncated,omitempty"`
+	Headers       map[string][]string `json:"headers,omitempty"`
+}
+
+type targetResult struct {
+	target   Target
+	response *http.Response
+	outcome  outcome
+	attempts int
+	duration time.Duration
+	// err is the transport error of the last attempt
+	err error
+}
+
+// forward sends the request to the target and retries failed attempts according to the targets retry policy
+func (h *HttpProxy) forward(ctx context.Context, log logr.Logger, receiver Receiver, dst Target, clone *http.Request, newBody func() io.ReadCloser) targetResult {
+	start := time.Now()
+	receiverNamespace, receiverName := receiver.owner()
+	attempts := max(dst.Retry.Attempts, 1)
+	result := targetResult{target: dst}
+
+	for attempt := 1; ; attempt++ {
+		result.attempts = attempt
+		req := clone.Clone(ctx)
+		req.RequestURI = ""
+		req.Body = newBody()
+
+		res, err := h.client.Do(req)
+		result.err = err
+		if err != nil {
+			res = &http.Response{
+				StatusCode: http.StatusGatewayTimeout,
+			}
+			result.outcome = outcomeFailure
+			log.Error(err, "forwarding request to clone backend failed", "target", clone.URL.Host, "service", dst.ServiceName, "namespace", dst.ServiceNamespace, "attempt", attempt)
+		} else {
+			result.outcome, err = dst.classify(ctx, res)
+			if err != nil {
+				log.Error(err, "failed to evaluate success expression", "expression", dst.SuccessExpression.String(), "service", dst.ServiceName, "namespace", dst.ServiceNamespace)
+			}
+
+			log.Info("forwarding request to clone backend finished", "status", res.StatusCode, "outcome", result.outcome, "target", clone.URL.Host, "service", dst.ServiceName, "namespace", dst.ServiceNamespace, "attempt", attempt)
+		}
+
+		result.response = res
+		if result.outcome != outcomeFailure || attempt >= attempts || !sleep(ctx, dst.Retry.Interval) {
+			break
+		}
+
+		closeBody(res)
+		targetRetriesTotal.WithLabelValues(receiverNamespace, receiverName, dst.ServiceName, dst.ServiceNamespace).Inc()
+	}
+
+	result.duration = time.Since(start)
+	targetRequestsTotal.WithLabelValues(receiverNamespace, receiverName, dst.ServiceName, dst.ServiceNamespace, string(result.outcome)).Inc()
+	targetRequestDuration.WithLabelValues(receiverNamespace, receiverName, dst.ServiceName, dst.ServiceNamespace, string(result.outcome)).Observe(result.duration.Seconds())
+
+	return result
+}
+
+// deliver forwards the request to the target unless its circuit is open and sends the result to results.
+// A delivery queued by an open circuit is reported as failed right away and forwarded once the circuit allows it.
+// Debounced and delayed deliveries are postponed before, a delivery dropped by debouncing does not send any result.
+// Ordered deliveries wait for their turn afterwards and release the next one once they finished.
+// Waiting deliveries are dropped once the proxy drains while deliveries which have been sent already are awaited.
+func (h *HttpProxy) deliver(ctx context.Context, log logr.Logger, receiver Receiver, dst Target, clone *http.Request, newBody func() io.ReadCloser, debounced func(context.Context) bool, ordered *turn, results chan<- targetResult) {
+	timeout := cmp.Or(dst.Timeout, receiver.Timeout)
+	receiverNamespace, receiverName := receiver.owner()
+	sendCtx := context.WithoutCancel(ctx)
+	defer ordered.done()
+
+	if debounced != nil {
+		if !debounced(ctx) {
+			_ = newBody().Close()
+			if ctx.Err() != nil {
+				h.dropDelivery(log, receiver, dst, "debounce")
+				return
+			}
+
+			targetDebouncedTotal.WithLabelValues(receiverNamespace, receiverName, dst.ServiceName, dst.ServiceNamespace).Inc()
+			log.Info("request debounced", "service", dst.ServiceName, "namespace", dst.ServiceNamespace)
+			return
+		}
+	}
+
+	if dst.Delay > 0 && !sleep(ctx, dst.Delay) {
+		_ = newBody().Close()
+		h.dropDelivery(log, receiver, dst, "delay")
+		return
+	}
+
+	if !ordered.wait(ctx) {
+		_ = newBody().Close()
+		h.dropDelivery(log, receiver, dst, "ordering")
+		return
+	}
+
+	breaker := h.breakers.get(receiver, dst)
+
+	ok, probe := breaker.allow()
+	if ok {
+		result := h.forward(withTimeout(sendCtx, timeout), log, receiver, dst, clone, newBody)
+		breaker.record(result.outcome, probe)
+		h.recordResult(receiver, result)
+		results <- result
+		return
+	}
+
+	action := cmp.Or(dst.CircuitBreaker.OpenAction, CircuitSkip)
+	if action == CircuitQueue && !breaker.enqueue() {
+		// A full queue bounds the memory and spool files held by queued deliveries
+		log.Info("circuit breaker queue is full", "limit", cmp.Or(dst.CircuitBreaker.QueueLimit, DefaultCircuitQueueLimit), "service", dst.ServiceName, "namespace", dst.ServiceNamespace)
+		action = CircuitDeadLetter
+	}
+
+	targetCircuitOpenTotal.WithLabelValues(receiverNamespace, receiverName, dst.ServiceName, dst.ServiceNamespace, string(action)).Inc()
+	log.Info("circuit breaker is open", "action", action, "service", dst.ServiceName, "namespace", dst.ServiceNamespace)
+
+	result := targetResult{
+		target:   dst,
+		response: &http.Response{StatusCode: http.StatusServiceUnavailable},
+		outcome:  outcomeFailure,
+		err:      errCircuitOpen,
+	}
+
+	if action != CircuitQueue {
+		body := newBody()
+		if action == CircuitDeadLetter {
+			h.recordDeadLetter(receiver, dst, clone, body)
+		}
+
+		_ = body.Close()
+		h.recordResult(receiver, result)
+		results <- result
+		return
+	}
+
+	results <- result
+
+	waitCtx, cancel := context.WithTimeout(ctx, cmp.Or(dst.CircuitBreaker.QueueTimeout, DefaultCircuitQueueTimeout))
+	probe, err := breaker.wait(waitCtx)
+	cancel()
+	breaker.dequeue()
+
+	if err != nil {
+		_ = newBody().Close()
+		h.recordResult(receiver, result)
+		if ctx.Err() != nil {
+			h.dropDelivery(log, receiver, dst, "circuit")
+			return
+		}
+
+		log.Info("queued delivery expired", "service", dst.ServiceName, "namespace", dst.ServiceNamespace)
+		return
+	}
+
+	result = h.forward(withTimeout(sendCtx, timeout), log, receiver, dst, clone, newBody)
+	breaker.record(result.outcome, probe)
+	h.recordResult(receiver, result)
+	closeBody(result.response)
+}
+
+// dropDelivery records a delivery which has been dropped while it was waiting to be sent since the proxy drains
+func (h *HttpProxy) dropDelivery(log logr.Logger, receiver Receiver, dst Target, waitingFor string) {
+	receiverNamespace, receiverName := receiver.owner()
+	targetDroppedTotal.WithLabelValues(receiverNamespace, receiverName, dst.ServiceName, dst.ServiceNamespace, waitingFor).Inc()
+	log.Info("delivery dropped on shutdown", "waitingFor", waitingFor, "service", dst.ServiceName, "namespace", dst.ServiceNamespace)
+}
+
+// recordResult records the result of a delivery in the delivery statistics and failures
+func (h *HttpProxy) recordResult(receiver Receiver, result targetResult) {
+	h.stats.record(receiver, result)
+	if result.outcome == outcomeFailure {
+		h.recordFailure(receiver, result)
+		if h.recorder != nil {
+			h.failureEvents.add(receiver, result)
+		}
+	}
+}
+
+// withTimeout derives a context which is done after the timeout, 0 means no timeout
+func withTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	if timeout <= 0 {
+		return ctx
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	context.AfterFunc(ctx, cancel)
+	return ctx
 }
 
 func (h *HttpProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	receiver, ok := h.receivers[r.URL.Path]
+	receiver, ok := h.lookup(r.URL.Path)
 	if !ok {
-		h.log.Info("no matching http backend for request", "request", r.RequestURI)
+		h.log.Info("no matching http backend for request", append(h.requestValues(r), "pathFingerprint", fingerprint(r.URL.Path))...)
 		w.WriteHeader(http.StatusServiceUnavailable)
 		return
 	}
 
+	log := h.receiverLogger(receiver).WithValues(h.requestValues(r)...)
+	traceReceiver(r.Context(), receiver)
+
 	var (
-		b   []byte
-		err error
+		b         []byte
+		err       error
+		body      io.Reader = r.Body
+		truncated bool
+		limit     = cmp.Or(receiver.BodySizeLimit, h.defaultBodySizeLimit)
+		reject    = receiver.BodySizeLimitAction == Reject
 	)
 
-	if receiver.BodySizeLimit == 0 {
-		b, err = io.ReadAll(r.Body)
-	} else {
-		limitedReader := &io.LimitedReader{R: r.Body, N: receiver.BodySizeLimit}
-		b, err = io.ReadAll(limitedReader)
+	if limit > 0 {
+		switch {
+		case reject && r.ContentLength > limit:
+			h.rejectOversizedBody(w, log, receiver, limit)
+			return
+		case reject && receiver.Streaming && r.ContentLength < 0:
+			// A streamed body is already sent to the targets, exceeding the limit midway would abort their requests
+			h.rejectUnknownBodyLength(w, log, receiver)
+			return
+		case reject:
+			body = http.MaxBytesReader(w, r.Body, limit)
+		case r.ContentLength > limit:
+			truncated = true
+			body = io.LimitReader(r.Body, limit)
+		default:
+			// read one more byte to find out if the body gets truncated
+			body = io.LimitReader(r.Body, limit+1)
+		}
 	}
 
-	if err != nil {
-		h.log.Error(err, "failed to read incoming body from request", "request", r.RequestURI)
-		return
-	}
+	if !receiver.Streaming {
+		b, err = io.ReadAll(body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				h.rejectOversizedBody(w, log, receiver, limit)
+				return
+			}
 
-	responses := make(chan *http.Response)
+			log.Error(err, "failed to read incoming body from request")
+			return
+		}
 
-	ctx := context.TODO()
+		if limit > 0 && int64(len(b)) > limit {
+			truncated = true
+			b = b[:limit]
+		}
+	}
 
-	h.log.Info("clone request to upstreams", "targets", len(receiver.Targets), "request", r.RequestURI)
+	if truncated {
+		h.recordTruncatedBody(log, receiver, limit)
+	}
+
+	log.Info("clone request to upstreams", "targets", len(receiver.Targets))
 
 	if len(receiver.Targets) == 0 {
-		h.log.Info("no targets found", "request", r.RequestURI)
+		log.Info("no targets found")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	var cancel context.CancelFunc
-	shouldCancel := true
-	if receiver.Timeout > 0 {
-		ctx, cancel = context.WithTimeout(ctx, receiver.Timeout)
-	}
+	// Sampled out targets are neither delivered nor awaited for this request
+	receiver.Targets = h.sample(log, receiver, r.Header, b)
+
+	// The upstream context is released once all targets finished and the response has been sent downstream.
+	// Targets which are not awaited (Async, mirrors or after an early response) keep running in the background.
+	// It is canceled as well once the proxy drains to drop the deliveries which are still waiting to be sent.
+	ctx, cancel := context.WithCancel(h.stopping)
+	results := make(chan targetResult, len(receiver.Targets))
+	var inFlight sync.WaitGroup
+	inFlight.Add(1)
+
+	go func() {
+		inFlight.Wait()
+		cancel()
+		close(results)
+	}()
 
 	defer func() {
-		if cancel != nil && shouldCancel {
-			cancel()
-		}
+		go discardResults(results)
+		inFlight.Done()
 	}()
 
+	var spools []*spool
+	pending := h.pendingDeliveries(receiver.Path)
+
 	for _, dst := range receiver.Targets {
 		h.wg.Add(1)
+		h.inFlight.Add(1)
+		pending.Add(1)
+		inFlight.Add(1)
 
-		go func(dst Target, r *http.Request, b []byte) {
-			defer h.wg.Done()
+		clone := r.Clone(ctx)
+		clone.URL.Scheme = "http"
+		clone.URL.Host = fmt.Sprintf("%s:%d", dst.Address, dst.Port)
+		clone.URL.Path = dst.Path
 
-			clone := r.Clone(ctx)
+		targetBody := b
+		targetTruncated := truncated
+		if dst.BodySizeLimit > 0 && int64(len(b)) > dst.BodySizeLimit {
+			targetBody = b[:dst.BodySizeLimit]
+			targetTruncated = true
+		}
 
-			clone.URL.Scheme = "http"
-			clone.URL.Host = fmt.Sprintf("%s:%d", dst.Address, dst.Port)
-			clone.URL.Path = dst.Path
-			clone.RequestURI = ""
+		newBody := func() io.ReadCloser {
+			return io.NopCloser(bytes.NewReader(targetBody))
+		}
 
-			clone.Body = io.NopCloser(bytes.NewReader(b))
+		if receiver.Streaming {
+			s := newSpool(cmp.Or(receiver.StreamBufferSize, DefaultStreamBufferSize), h.spoolDir)
+			spools = append(spools, s)
+			newBody = func() io.ReadCloser {
+				return s
+			}
 
-			res, err := h.client.Do(clone)
-			if err != nil {
-				res = &http.Response{
-					StatusCode: http.StatusGatewayTimeout,
+			if dst.BodySizeLimit > 0 {
+				targetTruncated = targetTruncated || r.ContentLength > dst.BodySizeLimit
+				newBody = func() io.ReadCloser {
+					return struct {
+						io.Reader
+						io.Closer
+					}{io.LimitReader(s, dst.BodySizeLimit), s}
 				}
-				h.log.Error(err, "forwarding request to clone backend failed", "request", r.RequestURI, "target", clone.URL.Host, "service", dst.ServiceName, "namespace", dst.ServiceNamespace)
-			} else {
-				h.log.Info("forwarding request to clone backend finished", "status", res.StatusCode, "target", clone.URL.Host, "service", dst.ServiceName, "namespace", dst.ServiceNamespace)
 			}
 
-			if receiver.ResponseType != Async {
-				responses <- res
+			// A streamed body can not be replayed
+			dst.Retry = RetryPolicy{}
+			if limit > 0 && r.ContentLength > limit || dst.BodySizeLimit > 0 && r.ContentLength > dst.BodySizeLimit {
+				clone.ContentLength = -1
 			}
-		}(dst, r, b)
-	}
+		} else {
+			clone.ContentLength = int64(len(targetBody))
+		}
 
-	if receiver.ResponseType == Async {
-		shouldCancel = false
-		h.log.Info("return response", "request", r.RequestURI, "status", http.StatusAccepted)
-		w.WriteHeader(http.StatusAccepted)
-		return
-	}
+		if targetTruncated {
+			clone.Header.Set(BodyTruncatedHeader, "true")
+		}
 
-	var lastResponse *http.Response
-	var returnResponse *http.Response
-	var received int
-	var reportResponse ReportResponse
+		var debounced func(context.Context) bool
+		if dst.Debounce != nil {
+			debounced = h.debouncer.debounce(targetKey{
+				path:    receiver.Path,
+				address: dst.Address,
+				port:    dst.Port,
+				key:     dst.Debounce.Key.extract(r.Header, b),
+			}, *dst.Debounce)
+		}
 
-	for response := range responses {
-		received++
+		var ordered *turn
+		if receiver.OrderingKey != nil {
+			ordered = h.sequencer.enter(targetKey{
+				path:    receiver.Path,
+				address: dst.Address,
+				port:    dst.Port,
+				key:     receiver.OrderingKey.extract(r.Header, b),
+			})
+		}
 
-		switch {
-		case receiver.ResponseType == AwaitAllReport:
-			body, err := io.ReadAll(response.Body)
-			if err != nil {
-				h.log.Error(err, "failed to read response body", "request", r.RequestURI)
-			}
+		go func(dst Target, clone *http.Request) {
+			defer h.wg.Done()
+			defer h.inFlight.Add(-1)
+			defer pending.Add(-1)
+			defer inFlight.Done()
 
-			defer func() {
-				_ = response.Body.Close()
-			}()
+			h.deliver(ctx, log, receiver, dst, clone, newBody, debounced, ordered, results)
+		}(dst, clone)
+	}
 
-			reportResponse.Targets = append(reportResponse.Targets, ReportTargetResponse{
-				StatusCode: response.StatusCode,
-				Body:       string(body),
-				Headers:    response.Header,
-			})
-		case returnResponse == nil && receiver.ResponseType == AwaitAllPreferSuccessful:
-			if response.StatusCode >= 200 && response.StatusCode < 300 {
-				returnResponse = response
-			}
-		case returnResponse == nil && receiver.ResponseType == AwaitAllPreferFailed:
-			if response.StatusCode >= 400 {
-				returnResponse = response
-			}
+	if receiver.Streaming {
+		streamTruncated, err := tee(body, spools, limit)
+		if err != nil {
+			log.Error(err, "failed to stream incoming body from request")
 		}
 
-		if received == len(receiver.Targets) {
-			lastResponse = response
-			close(responses)
-			break
+		// The requests to the targets are already sent, they can't be marked with BodyTruncatedHeader anymore
+		if streamTruncated {
+			h.recordTruncatedBody(log, receiver, limit)
 		}
 	}
 
+	// Without any awaitable target there is no response to await
+	if receiver.ResponseType == Async || !slices.ContainsFunc(receiver.Targets, Target.awaitable) {
+		log.Info("return response", "status", http.StatusAccepted)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
 	if receiver.ResponseType == AwaitAllReport {
+		statusCode, reportResponse := h.awaitReport(receiver, results)
+
 		body, err := json.Marshal(reportResponse)
 		if err != nil {
-			h.log.Error(err, "failed to marshal report response", "request", r.RequestURI)
+			log.Error(err, "failed to marshal report response")
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
+		log.Info("return response", "status", statusCode)
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
+		w.WriteHeader(statusCode)
 		_, err = w.Write(body)
 		if err != nil {
-			h.log.Error(err, "failed to write body", "request", r.RequestURI)
+			log.Error(err, "failed to write body")
 		}
 
 		return
 	}
 
-	if returnResponse == nil {
-		returnResponse = lastResponse
-	}
+	returnResponse := h.awaitResponse(receiver, results)
 
-	h.log.Info("return response", "request", r.RequestURI, "status", returnResponse.StatusCode)
+	log.Info("return response", "status", returnResponse.StatusCode)
 
 	for k, v := range returnResponse.Header {
 		for _, vv := range v {
@@ -257,18 +728,350 @@ func (h *HttpProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(returnResponse.StatusCode)
 
 	if returnResponse.Body != nil {
+		defer func() {
+			_ = returnResponse.Body.Close()
+		}()
+
 		_, err = io.Copy(w, returnResponse.Body)
 		if err != nil {
-			h.log.Error(err, "failed to write body", "request", r.RequestURI)
+			log.Error(err, "failed to write body")
 			return
 		}
+	}
+}
 
-		defer func() {
-			_ = returnResponse.Body.Close()
-		}()
+// sample returns the targets of the receiver which are sampled for the request
+func (h *HttpProxy) sample(log logr.Logger, receiver Receiver, header http.Header, body []byte) []Target {
+	receiverNamespace, receiverName := receiver.owner()
+	targets := make([]Target, 0, len(receiver.Targets))
+
+	for _, target := range receiver.Targets {
+		if target.Sampling.sample(header, body) {
+			targets = append(targets, target)
+			continue
+		}
+
+		targetSampledOutTotal.WithLabelValues(receiverNamespace, receiverName, target.ServiceName, target.ServiceNamespace).Inc()
+		log.Info("request sampled out", "service", target.ServiceName, "namespace", target.ServiceNamespace)
+	}
+
+	return targets
+}
+
+// recordTruncatedBody counts and logs a request body truncated to the body size limit
+func (h *HttpProxy) recordTruncatedBody(log logr.Logger, receiver Receiver, limit int64) {
+	receiverNamespace, receiverName := receiver.owner()
+	bodySizeLimitExceededTotal.WithLabelValues(receiverNamespace, receiverName, string(Truncate)).Inc()
+	log.Info("request body truncated", "limit", limit)
+}
+
+// rejectOversizedBody responds with 413 Payload Too Large and records an event on the receiver
+func (h *HttpProxy) rejectOversizedBody(w http.ResponseWriter, log logr.Logger, receiver Receiver, limit int64) {
+	receiverNamespace, receiverName := receiver.owner()
+	bodySizeLimitExceededTotal.WithLabelValues(receiverNamespace, receiverName, string(Reject)).Inc()
+	log.Info("request body exceeds the body size limit", "limit", limit)
+
+	if h.recorder != nil && receiver.Object != nil {
+		h.recorder.Eventf(receiver.Object, "Warning", "BodySizeLimitExceeded", "request rejected, body exceeds the limit of %d bytes", limit)
+	}
+
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+}
+
+// rejectUnknownBodyLength responds with 411 Length Required and records an event on the receiver
+func (h *HttpProxy) rejectUnknownBodyLength(w http.ResponseWriter, log logr.Logger, receiver Receiver) {
+	log.Info("streamed request body without content length can not be checked against the body size limit")
+
+	if h.recorder != nil && receiver.Object != nil {
+		h.recorder.Eventf(receiver.Object, "Warning", "BodyLengthRequired", "request rejected, streamed bodies require a content length to enforce the body size limit")
+	}
+
+	w.WriteHeader(http.StatusLengthRequired)
+}
+
+// awaitReport collects the responses of all targets and decides the status code of the report
+func (h *HttpProxy) awaitReport(receiver Receiver, results <-chan targetResult) (int, ReportResponse) {
+	var (
+		report ReportResponse
+		failed bool
+	)
+
+	for received := 0; received < receiver.awaited(); {
+		result := <-results
+		if !receiver.awaits(result.target) {
+			closeBody(result.response)
+			continue
+		}
+
+		received++
+		body, truncated, err := readLimitedBody(result.response, receiver.Report.BodySizeLimit)
+		if err != nil {
+			h.receiverLogger(receiver).Error(err, "failed to read response body", "service", result.target.ServiceName, "namespace", result.target.ServiceNamespace)
+		}
+
+		target := ReportTargetResponse{
+			Service:       result.target.ServiceName,
+			Namespace:     result.target.ServiceNamespace,
+			Path:          result.target.Path,
+			StatusCode:    result.response.StatusCode,
+			Outcome:       string(result.outcome),
+			Attempts:      result.attempts,
+			DurationMS:    result.duration.Milliseconds(),
+			Body:          string(body),
+			BodyTruncated: truncated,
+			Headers:       result.response.Header,
+		}
+
+		if result.err != nil {
+			target.Error = result.err.Error()
+		}
+
+		failed = failed || result.outcome == outcomeFailure
+		report.Targets = append(report.Targets, target)
+	}
+
+	switch {
+	case receiver.Report.StatusCode == ReportMultiStatus:
+		return http.StatusMultiStatus, report
+	case receiver.Report.StatusCode == ReportBadGatewayOnFailure && failed:
+		return http.StatusBadGateway, report
+	default:
+		return http.StatusOK, report
+	}
+}
+
+// awaitResponse collects target responses until the receivers response type
+// can decide which one is sent downstream.
+// Responses which are consumed but not selected are closed.
+func (h *HttpProxy) awaitResponse(receiver Receiver, results <-chan targetResult) (returnResponse *http.Response) {
+	var (
+		consumed   []*http.Response
+		selected   *http.Response
+		successful int
+		failed     int
+	)
+
+	defer func() {
+		for _, response := range consumed {
+			if response != returnResponse {
+				closeBody(response)
+			}
+		}
+	}()
+
+	expected := receiver.awaited()
+	quorum := receiver.quorum()
+
+	for received := 0; received < expected; {
+		result := <-results
+		response := result.response
+		consumed = append(consumed, response)
+
+		if !receiver.awaits(result.target) {
+			continue
+		}
+
+		received++
+
+		switch receiver.ResponseType {
+		case AwaitAllPreferSuccessful:
+			if selected == nil && result.outcome == outcomeSuccess {
+				selected = response
+			}
+		case AwaitAllPreferFailed:
+			if selected == nil && result.outcome == outcomeFailure {
+				selected = response
+			}
+		case FirstSuccessful, Primary:
+			if result.outcome == outcomeSuccess {
+				return response
+			}
+		case Quorum:
+			if result.outcome == outcomeSuccess {
+				successful++
+			} else {
+				failed++
+			}
+
+			if successful >= quorum || failed > expected-quorum {
+				return response
+			}
+		}
+
+		if received == expected && selected == nil {
+			selected = response
+		}
+	}
+
+	if selected == nil {
+		h.receiverLogger(receiver).Info("no primary target registered")
+		return &http.Response{
+			StatusCode: http.StatusBadGateway,
+		}
+	}
+
+	return selected
+}
+
+// awaitable reports whether the response of the target can be awaited at all.
+// Fire and forget, delayed and debounced targets are sent like mirrors.
+func (t Target) awaitable() bool {
+	return !t.FireAndForget && t.Delay == 0 && t.Debounce == nil
+}
+
+// awaits reports whether the response of the target is awaited by the response type.
+// Targets which are not awaitable and all but the primary targets of the Primary response type are sent like mirrors.
+func (r Receiver) awaits(target Target) bool {
+	if !target.awaitable() {
+		return false
+	}
+
+	return r.ResponseType != Primary || target.Primary
+}
+
+// awaited returns the number of targets whose response is awaited
+func (r Receiver) awaited() int {
+	var n int
+	for _, target := range r.Targets {
+		if r.awaits(target) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// quorum returns the number of successful responses required for the Quorum response type.
+// It defaults to a majority of the awaited targets and is capped at the number of awaited targets.
+func (r Receiver) quorum() int {
+	awaited := r.awaited()
+	if r.Quorum <= 0 {
+		return awaited/2 + 1
+	}
+
+	return min(r.Quorum, awaited)
+}
+
+// readLimitedBody reads and closes the response body.
+// If limit is greater than 0 only limit bytes are returned and truncated reports whether the body was longer.
+func readLimitedBody(response *http.Response, limit int64) (body []byte, truncated bool, err error) {
+	if response.Body == nil {
+		return nil, false, nil
+	}
+
+	defer closeBody(response)
+	if limit <= 0 {
+		body, err = io.ReadAll(response.Body)
+		return body, false, err
+	}
+
+	body, err = io.ReadAll(io.LimitReader(response.Body, limit+1))
+	if int64(len(body)) > limit {
+		return body[:limit], true, err
+	}
+
+	return body, false, err
+}
+
+func closeBody(response *http.Response) {
+	if response.Body != nil {
+		_ = response.Body.Close()
+	}
+}
+
+// tee copies the incoming body to all spools.
+// A failed spool only fails the request of its own target.
+// If limit is greater than 0 at most limit bytes are copied and truncated reports whether the body was longer.
+func tee(body io.Reader, spools []*spool, limit int64) (truncated bool, err error) {
+	buf := make([]byte, 32*1024)
+	failed := make([]bool, len(spools))
+	var written int64
+
+	for {
+		n, err := body.Read(buf)
+		if limit > 0 && written+int64(n) > limit {
+			n = int(limit - written)
+			truncated = true
+		}
+
+		written += int64(n)
+		if n > 0 {
+			for i, s := range spools {
+				if failed[i] {
+					continue
+				}
+
+				if _, err := s.Write(buf[:n]); err != nil {
+					failed[i] = true
+					s.CloseWrite(err)
+				}
+			}
+		}
+
+		if truncated && err == nil {
+			err = io.EOF
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+
+			for i, s := range spools {
+				if !failed[i] {
+					s.CloseWrite(err)
+				}
+			}
+
+			return truncated, err
+		}
+	}
+}
+
+// sleep waits for the given duration and returns false if the context is done before
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// discardResults closes the responses of targets which were not awaited.
+func discardResults(results <-chan targetResult) {
+	for result := range results {
+		closeBody(result.response)
 	}
 }
 
 func (h *HttpProxy) Close() {
 	h.wg.Wait()
 }
+
+// Drain drops the deliveries which are still waiting to be sent, like delayed or debounced ones,
+// and waits until all in-flight deliveries finished or the context is done
+func (h *HttpProxy) Drain(ctx context.Context) error {
+	h.stop()
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d deliveries still in flight: %w", h.InFlight(), ctx.Err())
+	}
+}
+
+// InFlight returns the number of deliveries to targets which are currently in progress
+func (h *HttpProxy) InFlight() int64 {
+	return h.inFlight.Load()
+}
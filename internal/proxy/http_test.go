@@ -1,10 +1,12 @@
 package proxy
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"sync"
 	"testing"
@@ -12,6 +14,9 @@ import (
 
 	"github.com/go-logr/logr"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 func TestRegisterOrUpdateBackend(t *testing.T) {
@@ -32,8 +37,8 @@ func TestRegisterOrUpdateBackend(t *testing.T) {
 
 	err := proxy.RegisterOrUpdate(receiver)
 	g.Expect(err).NotTo(HaveOccurred(), "could not update backend")
-	g.Expect(1).To(Equal(len(proxy.receivers)))
-	g.Expect(receiver).To(Equal(proxy.receivers["/test"]))
+	g.Expect(1).To(Equal(len(proxy.snapshot())))
+	g.Expect(receiver).To(Equal(proxy.snapshot()["/test"]))
 
 	receiver = Receiver{
 		Path:         "/test",
@@ -49,9 +54,9 @@ func TestRegisterOrUpdateBackend(t *testing.T) {
 
 	err = proxy.RegisterOrUpdate(receiver)
 	g.Expect(err).NotTo(HaveOccurred(), "could not update backend")
-	g.Expect(1).To(Equal(len(proxy.receivers)))
+	g.Expect(1).To(Equal(len(proxy.snapshot())))
 
-	g.Expect(receiver).To(Equal(proxy.receivers["/test"]))
+	g.Expect(receiver).To(Equal(proxy.snapshot()["/test"]))
 }
 
 func TestRemoveBackend(t *testing.T) {
@@ -72,7 +77,81 @@ func TestRemoveBackend(t *testing.T) {
 	_ = proxy.RegisterOrUpdate(receiver)
 	err := proxy.Unregister("/test")
 	g.Expect(err).To(Not(HaveOccurred()))
-	g.Expect(0).To(Equal(len(proxy.receivers)))
+	g.Expect(0).To(Equal(len(proxy.snapshot())))
+}
+
+func TestConcurrentRegisterAndServe(t *testing.T) {
+	g := NewWithT(t)
+
+	opts := DefaultOptions
+	opts.Client = &http.Client{
+		Transport: &dummyTransport{
+			transport: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+			},
+		},
+	}
+
+	proxy := New(opts)
+
+	const (
+		paths      = 8
+		writers    = 4
+		readers    = 8
+		iterations = 200
+	)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	codes := make(map[int]int)
+
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				path := fmt.Sprintf("/hook-%d", (i+w)%paths)
+				if i%3 == 0 {
+					_ = proxy.Unregister(path)
+					continue
+				}
+
+				_ = proxy.RegisterOrUpdate(Receiver{
+					Path:         path,
+					ResponseType: Async,
+					Targets: []Target{
+						{Address: fmt.Sprintf("target-%d", w), Port: 8080},
+					},
+				})
+			}
+		}(w)
+	}
+
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				req, _ := http.NewRequest("POST", fmt.Sprintf("http://example.com/hook-%d", (i+r)%paths), strings.NewReader("body"))
+				w := httptest.NewRecorder()
+				proxy.ServeHTTP(w, req)
+
+				mu.Lock()
+				codes[w.Code]++
+				mu.Unlock()
+			}
+		}(r)
+	}
+
+	wg.Wait()
+	proxy.Close()
+
+	for code := range codes {
+		g.Expect(code).To(BeElementOf(http.StatusAccepted, http.StatusServiceUnavailable))
+	}
+
+	g.Expect(codes[http.StatusAccepted] + codes[http.StatusServiceUnavailable]).To(Equal(readers * iterations))
+	g.Expect(len(proxy.snapshot())).To(BeNumerically("<=", paths))
 }
 
 func TestServeHTTP_NoMatchingBackend(t *testing.T) {
@@ -198,18 +277,6 @@ func TestServeHTTP(t *testing.T) {
 			},
 			expectedCode: 504,
 		},
-		{
-			name:         "Returns a report",
-			responseType: AwaitAllReport,
-			responses: []*http.Response{
-				{StatusCode: 201, Body: io.NopCloser(strings.NewReader("first"))},
-				{StatusCode: 200, Body: io.NopCloser(strings.NewReader("second")), Header: http.Header{"X-Test": []string{"value"}}},
-				{StatusCode: 500, Body: io.NopCloser(strings.NewReader("third")), Header: http.Header{"X-Test": []string{"value"}}},
-			},
-			expectedCode:    200,
-			expectedBody:    `{"targets":[{"statusCode":201,"body":"first"},{"statusCode":200,"body":"second","headers":{"X-Test":["value"]}},{"statusCode":500,"body":"third","headers":{"X-Test":["value"]}}]}`,
-			expectedHeaders: map[string]string{"Content-Type": "application/json"},
-		},
 	}
 
 	for _, test := range tests {
@@ -272,54 +339,726 @@ func TestServeHTTP(t *testing.T) {
 	}
 }
 
-func TestServeHTTP_BodySizeLimit(t *testing.T) {
+func TestServeHTTP_EarlyResponse(t *testing.T) {
+	type testTarget struct {
+		statusCode    int
+		body          string
+		primary       bool
+		blocked       bool
+		fireAndForget bool
+	}
+
+	tests := []struct {
+		name         string
+		responseType ResponseType
+		quorum       int
+		targets      []testTarget
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "FirstSuccessful returns without waiting for the remaining targets",
+			responseType: FirstSuccessful,
+			targets: []testTarget{
+				{statusCode: 200, body: "first"},
+				{blocked: true},
+			},
+			expectedCode: 200,
+			expectedBody: "first",
+		},
+		{
+			name:         "FirstSuccessful returns the last response if no target succeeded",
+			responseType: FirstSuccessful,
+			targets: []testTarget{
+				{statusCode: 500, body: "error"},
+				{statusCode: 500, body: "error"},
+			},
+			expectedCode: 500,
+			expectedBody: "error",
+		},
+		{
+			name:         "Quorum returns once the quorum is reached",
+			responseType: Quorum,
+			quorum:       2,
+			targets: []testTarget{
+				{statusCode: 200, body: "ok"},
+				{statusCode: 200, body: "ok"},
+				{blocked: true},
+			},
+			expectedCode: 200,
+			expectedBody: "ok",
+		},
+		{
+			name:         "Quorum defaults to a majority of the targets",
+			responseType: Quorum,
+			targets: []testTarget{
+				{statusCode: 202, body: "ok"},
+				{statusCode: 202, body: "ok"},
+				{blocked: true},
+			},
+			expectedCode: 202,
+			expectedBody: "ok",
+		},
+		{
+			name:         "Quorum returns a failed response once the quorum can not be reached anymore",
+			responseType: Quorum,
+			quorum:       2,
+			targets: []testTarget{
+				{statusCode: 503, body: "unavailable"},
+				{statusCode: 503, body: "unavailable"},
+				{blocked: true},
+			},
+			expectedCode: 503,
+			expectedBody: "unavailable",
+		},
+		{
+			name:         "Primary returns the primary response and does not wait for mirrors",
+			responseType: Primary,
+			targets: []testTarget{
+				{blocked: true},
+				{statusCode: 201, body: "primary", primary: true},
+			},
+			expectedCode: 201,
+			expectedBody: "primary",
+		},
+		{
+			name:         "Primary returns a failed primary response even if a mirror succeeded",
+			responseType: Primary,
+			targets: []testTarget{
+				{statusCode: 200, body: "mirror"},
+				{statusCode: 500, body: "primary", primary: true},
+			},
+			expectedCode: 500,
+			expectedBody: "primary",
+		},
+		{
+			name:         "Primary without a primary target returns a bad gateway",
+			responseType: Primary,
+			targets: []testTarget{
+				{statusCode: 200, body: "mirror"},
+			},
+			expectedCode: http.StatusBadGateway,
+		},
+		{
+			name:         "AwaitAllPreferFailed does not wait for fire and forget targets",
+			responseType: AwaitAllPreferFailed,
+			targets: []testTarget{
+				{statusCode: 200, body: "ok"},
+				{blocked: true, fireAndForget: true},
+			},
+			expectedCode: 200,
+			expectedBody: "ok",
+		},
+		{
+			name:         "Quorum only counts targets which are not fire and forget",
+			responseType: Quorum,
+			targets: []testTarget{
+				{statusCode: 503, body: "unavailable"},
+				{statusCode: 200, body: "ok", fireAndForget: true},
+				{statusCode: 200, body: "ok", fireAndForget: true},
+			},
+			expectedCode: 503,
+			expectedBody: "unavailable",
+		},
+		{
+			name:         "Primary ignores fire and forget targets",
+			responseType: Primary,
+			targets: []testTarget{
+				{statusCode: 201, body: "primary", primary: true},
+				{blocked: true, fireAndForget: true},
+			},
+			expectedCode: 201,
+			expectedBody: "primary",
+		},
+		{
+			name:         "Accepts the request if all targets are fire and forget",
+			responseType: AwaitAllPreferSuccessful,
+			targets: []testTarget{
+				{blocked: true, fireAndForget: true},
+			},
+			expectedCode: http.StatusAccepted,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			release := make(chan struct{})
+			var mu sync.Mutex
+			var called []string
+
+			opts := DefaultOptions
+			opts.Client = &http.Client{
+				Transport: &dummyTransport{
+					transport: func(r *http.Request) (*http.Response, error) {
+						mu.Lock()
+						called = append(called, r.URL.Host)
+						mu.Unlock()
+
+						var idx int
+						_, _ = fmt.Sscanf(r.URL.Host, "target%d:8080", &idx)
+						target := test.targets[idx]
+
+						if target.blocked {
+							<-release
+							return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("blocked"))}, nil
+						}
+
+						return &http.Response{StatusCode: target.statusCode, Body: io.NopCloser(strings.NewReader(target.body))}, nil
+					},
+				},
+			}
+
+			proxy := New(opts)
+			receiver := Receiver{
+				Path:         "/hook",
+				ResponseType: test.responseType,
+				Quorum:       test.quorum,
+			}
+
+			for i, target := range test.targets {
+				receiver.Targets = append(receiver.Targets, Target{
+					Address:       fmt.Sprintf("target%d", i),
+					Port:          8080,
+					ServiceName:   "service",
+					Primary:       target.primary,
+					FireAndForget: target.fireAndForget,
+				})
+			}
+
+			g.Expect(proxy.RegisterOrUpdate(receiver)).To(Succeed())
+
+			req, _ := http.NewRequest("POST", "http://example.com/hook", strings.NewReader("body"))
+			w := httptest.NewRecorder()
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				proxy.ServeHTTP(w, req)
+			}()
+
+			g.Eventually(done).Should(BeClosed())
+			close(release)
+			proxy.Close()
+
+			g.Expect(w.Code).To(Equal(test.expectedCode))
+			g.Expect(w.Body.String()).To(Equal(test.expectedBody))
+			g.Expect(called).To(HaveLen(len(test.targets)))
+		})
+	}
+}
+
+func TestServeHTTP_SuccessCriteria(t *testing.T) {
 	g := NewWithT(t)
 
+	opts := DefaultOptions
+	opts.Client = &http.Client{
+		Transport: &dummyTransport{
+			transport: func(r *http.Request) (*http.Response, error) {
+				if r.URL.Host == "conflict:8080" {
+					return &http.Response{StatusCode: 409, Body: io.NopCloser(strings.NewReader("duplicate"))}, nil
+				}
+
+				return &http.Response{StatusCode: 500, Body: io.NopCloser(strings.NewReader("error"))}, nil
+			},
+		},
+	}
+
+	proxy := New(opts)
+	receiver := Receiver{
+		Path:         "/hook",
+		ResponseType: AwaitAllPreferSuccessful,
+		Targets: []Target{
+			{
+				Address:      "conflict",
+				Port:         8080,
+				SuccessCodes: []StatusCodeRange{{From: 200, To: 299}, {From: 409, To: 409}},
+			},
+			{
+				Address: "error",
+				Port:    8080,
+			},
+		},
+	}
+
+	g.Expect(proxy.RegisterOrUpdate(receiver)).To(Succeed())
+
+	req, _ := http.NewRequest("POST", "http://example.com/hook", strings.NewReader("body"))
+	w := httptest.NewRecorder()
+	proxy.ServeHTTP(w, req)
+	proxy.Close()
+
+	g.Expect(w.Code).To(Equal(409))
+	g.Expect(w.Body.String()).To(Equal("duplicate"))
+}
+
+func TestServeHTTP_Retry(t *testing.T) {
 	tests := []struct {
-		name            string
-		bodySizeLimit   int64
-		requestBody     string
-		expectedReadLen int
+		name             string
+		retry            RetryPolicy
+		failures         int
+		successCodes     []StatusCodeRange
+		expectedCode     int
+		expectedAttempts int
+	}{
+		{
+			name:             "Failed requests are not retried by default",
+			failures:         1,
+			expectedCode:     503,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "Failed requests are retried until successful",
+			retry:            RetryPolicy{Attempts: 3, Interval: time.Millisecond},
+			failures:         2,
+			expectedCode:     200,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "Returns the last failed response once all attempts are exhausted",
+			retry:            RetryPolicy{Attempts: 2, Interval: time.Millisecond},
+			failures:         5,
+			expectedCode:     503,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "Responses matching success codes are not retried",
+			retry:            RetryPolicy{Attempts: 3, Interval: time.Millisecond},
+			successCodes:     []StatusCodeRange{{From: 503, To: 503}},
+			failures:         5,
+			expectedCode:     503,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			var mu sync.Mutex
+			var attempts int
+
+			opts := DefaultOptions
+			opts.Client = &http.Client{
+				Transport: &dummyTransport{
+					transport: func(r *http.Request) (*http.Response, error) {
+						mu.Lock()
+						defer mu.Unlock()
+						attempts++
+
+						body, _ := io.ReadAll(r.Body)
+						g.Expect(string(body)).To(Equal("body"), "every attempt must send the full body")
+
+						if attempts <= test.failures {
+							return &http.Response{StatusCode: 503, Body: io.NopCloser(strings.NewReader("unavailable"))}, nil
+						}
+
+						return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+					},
+				},
+			}
+
+			proxy := New(opts)
+			receiver := Receiver{
+				Path:         "/hook",
+				ResponseType: AwaitAllPreferSuccessful,
+				Targets: []Target{
+					{
+						Address:      "target",
+						Port:         8080,
+						Retry:        test.retry,
+						SuccessCodes: test.successCodes,
+					},
+				},
+			}
+
+			g.Expect(proxy.RegisterOrUpdate(receiver)).To(Succeed())
+
+			req, _ := http.NewRequest("POST", "http://example.com/hook", strings.NewReader("body"))
+			w := httptest.NewRecorder()
+			proxy.ServeHTTP(w, req)
+			proxy.Close()
+
+			g.Expect(w.Code).To(Equal(test.expectedCode))
+			g.Expect(attempts).To(Equal(test.expectedAttempts))
+		})
+	}
+}
+
+func TestServeHTTP_Report(t *testing.T) {
+	type testTarget struct {
+		statusCode    int
+		body          string
+		header        http.Header
+		err           error
+		fireAndForget bool
+	}
+
+	tests := []struct {
+		name           string
+		report         ReportOptions
+		targets        []testTarget
+		expectedCode   int
+		expectedReport []ReportTargetResponse
+	}{
+		{
+			name: "Returns a report with status 200 by default",
+			targets: []testTarget{
+				{statusCode: 201, body: "first"},
+				{statusCode: 200, body: "second", header: http.Header{"X-Test": []string{"value"}}},
+				{statusCode: 500, body: "third"},
+			},
+			expectedCode: 200,
+			expectedReport: []ReportTargetResponse{
+				{Service: "service", Namespace: "target0", Path: "/path", StatusCode: 201, Outcome: "success", Attempts: 1, Body: "first"},
+				{Service: "service", Namespace: "target1", Path: "/path", StatusCode: 200, Outcome: "success", Attempts: 1, Body: "second", Headers: map[string][]string{"X-Test": {"value"}}},
+				{Service: "service", Namespace: "target2", Path: "/path", StatusCode: 500, Outcome: "failure", Attempts: 1, Body: "third"},
+			},
+		},
+		{
+			name:   "Returns a multi status report",
+			report: ReportOptions{StatusCode: ReportMultiStatus},
+			targets: []testTarget{
+				{statusCode: 200, body: "first"},
+			},
+			expectedCode: 207,
+			expectedReport: []ReportTargetResponse{
+				{Service: "service", Namespace: "target0", Path: "/path", StatusCode: 200, Outcome: "success", Attempts: 1, Body: "first"},
+			},
+		},
+		{
+			name:   "Returns a bad gateway if any target failed",
+			report: ReportOptions{StatusCode: ReportBadGatewayOnFailure},
+			targets: []testTarget{
+				{statusCode: 200, body: "first"},
+				{err: fmt.Errorf("connection refused")},
+			},
+			expectedCode: 502,
+			expectedReport: []ReportTargetResponse{
+				{Service: "service", Namespace: "target0", Path: "/path", StatusCode: 200, Outcome: "success", Attempts: 1, Body: "first"},
+				{Service: "service", Namespace: "target1", Path: "/path", StatusCode: 504, Outcome: "failure", Attempts: 1, Error: `Post "http://target1:8080/path": connection refused`},
+			},
+		},
+		{
+			name:   "Returns 200 if no target failed",
+			report: ReportOptions{StatusCode: ReportBadGatewayOnFailure},
+			targets: []testTarget{
+				{statusCode: 200, body: "first"},
+			},
+			expectedCode: 200,
+			expectedReport: []ReportTargetResponse{
+				{Service: "service", Namespace: "target0", Path: "/path", StatusCode: 200, Outcome: "success", Attempts: 1, Body: "first"},
+			},
+		},
+		{
+			name:   "Truncates response bodies",
+			report: ReportOptions{BodySizeLimit: 4},
+			targets: []testTarget{
+				{statusCode: 200, body: "truncated"},
+				{statusCode: 200, body: "full"},
+			},
+			expectedCode: 200,
+			expectedReport: []ReportTargetResponse{
+				{Service: "service", Namespace: "target0", Path: "/path", StatusCode: 200, Outcome: "success", Attempts: 1, Body: "trun", BodyTruncated: true},
+				{Service: "service", Namespace: "target1", Path: "/path", StatusCode: 200, Outcome: "success", Attempts: 1, Body: "full"},
+			},
+		},
+		{
+			name: "Excludes fire and forget targets",
+			targets: []testTarget{
+				{statusCode: 200, body: "first"},
+				{statusCode: 500, body: "second", fireAndForget: true},
+			},
+			expectedCode: 200,
+			expectedReport: []ReportTargetResponse{
+				{Service: "service", Namespace: "target0", Path: "/path", StatusCode: 200, Outcome: "success", Attempts: 1, Body: "first"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			opts := DefaultOptions
+			opts.Client = &http.Client{
+				Transport: &dummyTransport{
+					transport: func(r *http.Request) (*http.Response, error) {
+						var idx int
+						_, _ = fmt.Sscanf(r.URL.Host, "target%d:8080", &idx)
+						target := test.targets[idx]
+
+						if target.err != nil {
+							return nil, target.err
+						}
+
+						return &http.Response{StatusCode: target.statusCode, Header: target.header, Body: io.NopCloser(strings.NewReader(target.body))}, nil
+					},
+				},
+			}
+
+			proxy := New(opts)
+			receiver := Receiver{
+				Path:         "/hook",
+				ResponseType: AwaitAllReport,
+				Report:       test.report,
+			}
+
+			for i, target := range test.targets {
+				receiver.Targets = append(receiver.Targets, Target{
+					Address:          fmt.Sprintf("target%d", i),
+					Port:             8080,
+					Path:             "/path",
+					ServiceName:      "service",
+					ServiceNamespace: fmt.Sprintf("target%d", i),
+					FireAndForget:    target.fireAndForget,
+				})
+			}
+
+			g.Expect(proxy.RegisterOrUpdate(receiver)).To(Succeed())
+
+			req, _ := http.NewRequest("POST", "http://example.com/hook", strings.NewReader("body"))
+			w := httptest.NewRecorder()
+			proxy.ServeHTTP(w, req)
+			proxy.Close()
+
+			g.Expect(w.Code).To(Equal(test.expectedCode))
+			g.Expect(w.Header().Get("Content-Type")).To(Equal("application/json"))
+
+			var report ReportResponse
+			g.Expect(json.Unmarshal(w.Body.Bytes(), &report)).To(Succeed())
+
+			for i := range report.Targets {
+				g.Expect(report.Targets[i].DurationMS).To(BeNumerically(">=", 0))
+				report.Targets[i].DurationMS = 0
+			}
+
+			g.Expect(report.Targets).To(ConsistOf(test.expectedReport))
+		})
+	}
+}
+
+func TestServeHTTP_BodySizeLimit(t *testing.T) {
+	tests := []struct {
+		name                   string
+		bodySizeLimit          int64
+		defaultBodySizeLimit   int64
+		targetBodySizeLimit    int64
+		action                 BodySizeLimitAction
+		streaming              bool
+		unknownLength          bool
+		requestBody            string
+		expectedCode           int
+		expectedReadLen        int
+		expectedTruncated      bool
+		expectedTruncatedTotal float64
+		expectedEvent          string
 	}{
 		{
 			name:            "No limit reads full body",
 			bodySizeLimit:   0,
 			requestBody:     "this is a test body",
+			expectedCode:    http.StatusAccepted,
 			expectedReadLen: len("this is a test body"),
 		},
 		{
-			name:            "With limit reads only up to limit",
-			bodySizeLimit:   5,
+			name:                   "With limit reads only up to limit",
+			bodySizeLimit:          5,
+			requestBody:            "this is a test body",
+			expectedCode:           http.StatusAccepted,
+			expectedReadLen:        5,
+			expectedTruncated:      true,
+			expectedTruncatedTotal: 1,
+		},
+		{
+			name:            "Body within the limit is not marked as truncated",
+			bodySizeLimit:   19,
+			unknownLength:   true,
+			requestBody:     "this is a test body",
+			expectedCode:    http.StatusAccepted,
+			expectedReadLen: 19,
+		},
+		{
+			name:                   "Truncated body with unknown length is marked as truncated",
+			bodySizeLimit:          5,
+			unknownLength:          true,
+			requestBody:            "this is a test body",
+			expectedCode:           http.StatusAccepted,
+			expectedReadLen:        5,
+			expectedTruncated:      true,
+			expectedTruncatedTotal: 1,
+		},
+		{
+			name:                   "Truncated streamed body is marked as truncated",
+			bodySizeLimit:          5,
+			streaming:              true,
+			requestBody:            "this is a test body",
+			expectedCode:           http.StatusAccepted,
+			expectedReadLen:        5,
+			expectedTruncated:      true,
+			expectedTruncatedTotal: 1,
+		},
+		{
+			name:                   "Truncated streamed body with unknown length is counted but can't be marked",
+			bodySizeLimit:          5,
+			streaming:              true,
+			unknownLength:          true,
+			requestBody:            "this is a test body",
+			expectedCode:           http.StatusAccepted,
+			expectedReadLen:        5,
+			expectedTruncatedTotal: 1,
+		},
+		{
+			name:            "Streamed body with unknown length within the limit is not counted",
+			bodySizeLimit:   19,
+			streaming:       true,
+			unknownLength:   true,
 			requestBody:     "this is a test body",
-			expectedReadLen: 5,
+			expectedCode:    http.StatusAccepted,
+			expectedReadLen: 19,
+		},
+		{
+			name:                   "Default limit applies to receivers without limit",
+			defaultBodySizeLimit:   4,
+			requestBody:            "this is a test body",
+			expectedCode:           http.StatusAccepted,
+			expectedReadLen:        4,
+			expectedTruncated:      true,
+			expectedTruncatedTotal: 1,
+		},
+		{
+			name:          "Reject oversized body",
+			bodySizeLimit: 5,
+			action:        Reject,
+			requestBody:   "this is a test body",
+			expectedCode:  http.StatusRequestEntityTooLarge,
+			expectedEvent: "Warning BodySizeLimitExceeded request rejected, body exceeds the limit of 5 bytes",
+		},
+		{
+			name:          "Reject oversized body with unknown length",
+			bodySizeLimit: 5,
+			action:        Reject,
+			unknownLength: true,
+			requestBody:   "this is a test body",
+			expectedCode:  http.StatusRequestEntityTooLarge,
+			expectedEvent: "Warning BodySizeLimitExceeded request rejected, body exceeds the limit of 5 bytes",
+		},
+		{
+			name:            "Reject forwards body within the limit",
+			bodySizeLimit:   19,
+			action:          Reject,
+			requestBody:     "this is a test body",
+			expectedCode:    http.StatusAccepted,
+			expectedReadLen: 19,
+		},
+		{
+			name:          "Reject streamed body with unknown length",
+			bodySizeLimit: 19,
+			action:        Reject,
+			streaming:     true,
+			unknownLength: true,
+			requestBody:   "this is a test body",
+			expectedCode:  http.StatusLengthRequired,
+			expectedEvent: "Warning BodyLengthRequired request rejected, streamed bodies require a content length to enforce the body size limit",
+		},
+		{
+			name:          "Reject oversized streamed body",
+			bodySizeLimit: 5,
+			action:        Reject,
+			streaming:     true,
+			requestBody:   "this is a test body",
+			expectedCode:  http.StatusRequestEntityTooLarge,
+			expectedEvent: "Warning BodySizeLimitExceeded request rejected, body exceeds the limit of 5 bytes",
+		},
+		{
+			name:            "Reject forwards streamed body within the limit",
+			bodySizeLimit:   19,
+			action:          Reject,
+			streaming:       true,
+			requestBody:     "this is a test body",
+			expectedCode:    http.StatusAccepted,
+			expectedReadLen: 19,
+		},
+		{
+			name:                "Target limit truncates the body forwarded to the target",
+			targetBodySizeLimit: 4,
+			requestBody:         "this is a test body",
+			expectedCode:        http.StatusAccepted,
+			expectedReadLen:     4,
+			expectedTruncated:   true,
+		},
+		{
+			name:                   "Smaller receiver limit takes precedence over the target limit",
+			bodySizeLimit:          2,
+			targetBodySizeLimit:    4,
+			requestBody:            "this is a test body",
+			expectedCode:           http.StatusAccepted,
+			expectedReadLen:        2,
+			expectedTruncated:      true,
+			expectedTruncatedTotal: 1,
+		},
+		{
+			name:                "Target limit does not reject the request",
+			bodySizeLimit:       19,
+			action:              Reject,
+			targetBodySizeLimit: 4,
+			requestBody:         "this is a test body",
+			expectedCode:        http.StatusAccepted,
+			expectedReadLen:     4,
+			expectedTruncated:   true,
+		},
+		{
+			name:                "Target limit truncates a streamed body",
+			targetBodySizeLimit: 4,
+			streaming:           true,
+			requestBody:         "this is a test body",
+			expectedCode:        http.StatusAccepted,
+			expectedReadLen:     4,
+			expectedTruncated:   true,
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
 			var mu sync.Mutex
 			var receivedBody []byte
+			var truncatedHeader string
 
+			recorder := record.NewFakeRecorder(10)
 			opts := DefaultOptions
-			opts.Client.Transport = &dummyTransport{
-				transport: func(r *http.Request) (*http.Response, error) {
-					mu.Lock()
-					defer mu.Unlock()
-					receivedBody, _ = io.ReadAll(r.Body)
-					return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+			opts.Recorder = recorder
+			opts.DefaultBodySizeLimit = test.defaultBodySizeLimit
+			opts.Client = &http.Client{
+				Transport: &dummyTransport{
+					transport: func(r *http.Request) (*http.Response, error) {
+						b, err := io.ReadAll(r.Body)
+						if err != nil {
+							return nil, err
+						}
+
+						mu.Lock()
+						defer mu.Unlock()
+						receivedBody = b
+						truncatedHeader = r.Header.Get(BodyTruncatedHeader)
+						return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+					},
 				},
 			}
 			proxy := New(opts)
 
 			receiver := Receiver{
-				Path:          "/test",
-				ResponseType:  Async,
-				BodySizeLimit: test.bodySizeLimit,
+				Path:                "/test",
+				ResponseType:        Async,
+				BodySizeLimit:       test.bodySizeLimit,
+				BodySizeLimitAction: test.action,
+				Streaming:           test.streaming,
+				Object:              &corev1.ObjectReference{Kind: "Receiver", Namespace: "default", Name: "receiver"},
 				Targets: []Target{
 					{
-						Address:     "target",
-						Port:        8080,
-						ServiceName: "service",
+						Address:       "target",
+						Port:          8080,
+						ServiceName:   "service",
+						BodySizeLimit: test.targetBodySizeLimit,
 					},
 				},
 			}
@@ -328,11 +1067,113 @@ func TestServeHTTP_BodySizeLimit(t *testing.T) {
 			g.Expect(err).NotTo(HaveOccurred())
 
 			req, _ := http.NewRequest("POST", "http://example.com/test", strings.NewReader(test.requestBody))
+			if test.unknownLength {
+				// like a chunked request received by the server
+				req.ContentLength = -1
+			}
+
+			truncatedTotal := bodySizeLimitExceededTotal.WithLabelValues("default", "receiver", string(Truncate))
+			truncatedBefore := testutil.ToFloat64(truncatedTotal)
+
 			w := httptest.NewRecorder()
 			proxy.ServeHTTP(w, req)
 			proxy.Close()
 
+			g.Expect(w.Code).To(Equal(test.expectedCode))
 			g.Expect(test.expectedReadLen).To(Equal(len(receivedBody)))
+			g.Expect(truncatedHeader == "true").To(Equal(test.expectedTruncated))
+			g.Expect(testutil.ToFloat64(truncatedTotal) - truncatedBefore).To(Equal(test.expectedTruncatedTotal))
+
+			if test.expectedEvent == "" {
+				g.Expect(recorder.Events).To(BeEmpty())
+			} else {
+				g.Expect(recorder.Events).To(Receive(Equal(test.expectedEvent)))
+			}
+		})
+	}
+}
+
+func TestServeHTTP_Streaming(t *testing.T) {
+	tests := []struct {
+		name          string
+		bodySizeLimit int64
+		requestBody   string
+		expectedBody  string
+	}{
+		{
+			name:         "Streams the full body to all targets",
+			requestBody:  strings.Repeat("streamed body ", 1024),
+			expectedBody: strings.Repeat("streamed body ", 1024),
+		},
+		{
+			name:          "Streams up to the body size limit",
+			bodySizeLimit: 8,
+			requestBody:   strings.Repeat("streamed body ", 1024),
+			expectedBody:  "streamed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			release := make(chan struct{})
+			var mu sync.Mutex
+			received := make(map[string]string)
+
+			opts := DefaultOptions
+			opts.SpoolDir = t.TempDir()
+			opts.Client = &http.Client{
+				Transport: &dummyTransport{
+					transport: func(r *http.Request) (*http.Response, error) {
+						// the slow target only starts reading once the body has been fully received
+						if r.URL.Host == "slow:8080" {
+							<-release
+						}
+
+						b, err := io.ReadAll(r.Body)
+						_ = r.Body.Close()
+						if err != nil {
+							return nil, err
+						}
+
+						mu.Lock()
+						received[r.URL.Host] = string(b)
+						mu.Unlock()
+
+						return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+					},
+				},
+			}
+
+			proxy := New(opts)
+			receiver := Receiver{
+				Path:             "/hook",
+				ResponseType:     Async,
+				Streaming:        true,
+				StreamBufferSize: 64,
+				BodySizeLimit:    test.bodySizeLimit,
+				Targets: []Target{
+					{Address: "fast", Port: 8080},
+					{Address: "slow", Port: 8080},
+				},
+			}
+
+			g.Expect(proxy.RegisterOrUpdate(receiver)).To(Succeed())
+
+			req, _ := http.NewRequest("POST", "http://example.com/hook", strings.NewReader(test.requestBody))
+			w := httptest.NewRecorder()
+			proxy.ServeHTTP(w, req)
+			g.Expect(w.Code).To(Equal(http.StatusAccepted))
+
+			close(release)
+			proxy.Close()
+
+			g.Expect(received).To(HaveKeyWithValue("fast:8080", test.expectedBody))
+			g.Expect(received).To(HaveKeyWithValue("slow:8080", test.expectedBody))
+
+			entries, err := os.ReadDir(opts.SpoolDir)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(entries).To(BeEmpty())
 		})
 	}
 }
@@ -379,6 +1220,52 @@ func TestServeHTTP_Timeout(t *testing.T) {
 	g.Expect(http.StatusOK).To(Equal(w.Code))
 }
 
+func TestServeHTTP_TargetTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	opts := DefaultOptions
+	opts.Client = &http.Client{
+		Transport: &dummyTransport{
+			transport: func(r *http.Request) (*http.Response, error) {
+				if r.URL.Host == "slow:8080" {
+					<-r.Context().Done()
+					return nil, r.Context().Err()
+				}
+
+				return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+			},
+		},
+	}
+	proxy := New(opts)
+
+	receiver := Receiver{
+		Path:         "/test",
+		ResponseType: AwaitAllPreferFailed,
+		Timeout:      time.Hour,
+		Targets: []Target{
+			{Address: "fast", Port: 8080},
+			{Address: "slow", Port: 8080, Timeout: 10 * time.Millisecond},
+		},
+	}
+
+	g.Expect(proxy.RegisterOrUpdate(receiver)).To(Succeed())
+
+	req, _ := http.NewRequest("POST", "http://example.com/test", strings.NewReader("body"))
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		proxy.ServeHTTP(w, req)
+	}()
+
+	// the target timeout overrides the timeout of the receiver
+	g.Eventually(done).Should(BeClosed())
+	proxy.Close()
+
+	g.Expect(w.Code).To(Equal(http.StatusGatewayTimeout))
+}
+
 func TestNew(t *testing.T) {
 	g := NewWithT(t)
 
@@ -390,9 +1277,9 @@ func TestNew(t *testing.T) {
 	proxy := New(opts)
 
 	g.Expect(proxy).NotTo(BeNil())
-	g.Expect(proxy.receivers).NotTo(BeNil())
+	g.Expect(proxy.snapshot()).NotTo(BeNil())
 	g.Expect(proxy.client).To(Equal(opts.Client))
-	g.Expect(len(proxy.receivers)).To(Equal(0))
+	g.Expect(len(proxy.snapshot())).To(Equal(0))
 }
 
 type dummyTransport struct {
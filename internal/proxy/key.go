@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// RequestKey extracts a key from a request, either from a header or from a field of the json body
+type RequestKey struct {
+	Header string
+	// BodyField is the dot separated path of a field in the json body, e.g. repository.full_name
+	BodyField string
+}
+
+// targetKey identifies the requests with the same key to a target of a receiver
+type targetKey struct {
+	path    string
+	address string
+	port    int32
+	key     string
+}
+
+// extract returns the key of the request, empty if the header or body field does not exist.
+// Fields which are not a string are json encoded.
+func (k RequestKey) extract(header http.Header, body []byte) string {
+	if k.Header != "" {
+		return header.Get(k.Header)
+	}
+
+	if k.BodyField == "" {
+		return ""
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return ""
+	}
+
+	for _, field := range strings.Split(k.BodyField, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return ""
+		}
+
+		value, ok = object[field]
+		if !ok {
+			return ""
+		}
+	}
+
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
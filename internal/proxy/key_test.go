@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRequestKey(t *testing.T) {
+	body := []byte(`{"repository":{"full_name":"org/repo","id":42,"private":false},"ref":"main"}`)
+
+	tests := []struct {
+		name        string
+		key         RequestKey
+		header      http.Header
+		body        []byte
+		expectedKey string
+	}{
+		{
+			name:        "Header",
+			key:         RequestKey{Header: "X-GitHub-Event"},
+			header:      http.Header{"X-Github-Event": []string{"push"}},
+			expectedKey: "push",
+		},
+		{
+			name:        "Missing header",
+			key:         RequestKey{Header: "X-GitHub-Event"},
+			header:      http.Header{},
+			expectedKey: "",
+		},
+		{
+			name:        "Top level body field",
+			key:         RequestKey{BodyField: "ref"},
+			body:        body,
+			expectedKey: "main",
+		},
+		{
+			name:        "Nested body field",
+			key:         RequestKey{BodyField: "repository.full_name"},
+			body:        body,
+			expectedKey: "org/repo",
+		},
+		{
+			name:        "Body field which is not a string",
+			key:         RequestKey{BodyField: "repository.id"},
+			body:        body,
+			expectedKey: "42",
+		},
+		{
+			name:        "Missing body field",
+			key:         RequestKey{BodyField: "repository.owner.login"},
+			body:        body,
+			expectedKey: "",
+		},
+		{
+			name:        "Body which is not json",
+			key:         RequestKey{BodyField: "ref"},
+			body:        []byte("ref=main"),
+			expectedKey: "",
+		},
+		{
+			name:        "Empty key",
+			body:        body,
+			expectedKey: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(test.key.extract(test.header, test.body)).To(Equal(test.expectedKey))
+		})
+	}
+}
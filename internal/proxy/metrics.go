@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	targetRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_controller_target_requests_total",
+			Help: "Total number of requests forwarded to targets partitioned by outcome.",
+		},
+		[]string{"receiver_namespace", "receiver_name", "service", "namespace", "outcome"},
+	)
+
+	targetRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "webhook_controller_target_request_duration_seconds",
+			Help:    "Duration of requests forwarded to targets including retries.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"receiver_namespace", "receiver_name", "service", "namespace", "outcome"},
+	)
+
+	bodySizeLimitExceededTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_controller_body_size_limit_exceeded_total",
+			Help: "Total number of requests exceeding the body size limit partitioned by the action taken.",
+		},
+		[]string{"receiver_namespace", "receiver_name", "action"},
+	)
+
+	targetRetriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_controller_target_retries_total",
+			Help: "Total number of retried requests to targets.",
+		},
+		[]string{"receiver_namespace", "receiver_name", "service", "namespace"},
+	)
+
+	targetDebouncedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_controller_target_debounced_total",
+			Help: "Total number of requests to targets dropped by debouncing.",
+		},
+		[]string{"receiver_namespace", "receiver_name", "service", "namespace"},
+	)
+
+	targetDroppedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_controller_target_dropped_total",
+			Help: "Total number of deliveries to targets dropped on shutdown partitioned by what they were waiting for.",
+		},
+		[]string{"receiver_namespace", "receiver_name", "service", "namespace", "waiting_for"},
+	)
+
+	targetSampledOutTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_controller_target_sampled_out_total",
+			Help: "Total number of requests not forwarded to targets since they were sampled out.",
+		},
+		[]string{"receiver_namespace", "receiver_name", "service", "namespace"},
+	)
+
+	targetCircuitState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "webhook_controller_target_circuit_state",
+			Help: "State of the circuit breaker of targets, 1 for the current state and 0 for the others.",
+		},
+		[]string{"receiver_namespace", "receiver_name", "service", "namespace", "state"},
+	)
+
+	targetCircuitQueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "webhook_controller_target_circuit_queue_depth",
+			Help: "Number of deliveries queued until the circuit of targets allows them.",
+		},
+		[]string{"receiver_namespace", "receiver_name", "service", "namespace"},
+	)
+
+	targetCircuitOpenTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_controller_target_circuit_open_total",
+			Help: "Total number of deliveries not sent to targets since their circuit was open partitioned by the action taken.",
+		},
+		[]string{"receiver_namespace", "receiver_name", "service", "namespace", "action"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		targetRequestsTotal,
+		targetRequestDuration,
+		targetRetriesTotal,
+		bodySizeLimitExceededTotal,
+		targetCircuitState,
+		targetCircuitQueueDepth,
+		targetCircuitOpenTotal,
+		targetDebouncedTotal,
+		targetDroppedTotal,
+		targetSampledOutTotal,
+	)
+}
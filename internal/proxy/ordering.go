@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"context"
+	"sync"
+)
+
+// sequencer serializes the deliveries with the same ordering key to a target
+type sequencer struct {
+	mu    sync.Mutex
+	tails map[targetKey]chan struct{}
+}
+
+// turn is the place of a delivery in the sequence of its key, a nil turn is never ordered
+type turn struct {
+	previous <-chan struct{}
+	release  func()
+}
+
+// enter appends a delivery to the sequence of its key in the order requests are received.
+// The turn must be released once the delivery finished, including its retries.
+func (s *sequencer) enter(key targetKey) *turn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tails == nil {
+		s.tails = make(map[targetKey]chan struct{})
+	}
+
+	previous := s.tails[key]
+	done := make(chan struct{})
+	s.tails[key] = done
+
+	return &turn{
+		previous: previous,
+		release: sync.OnceFunc(func() {
+			s.mu.Lock()
+			if s.tails[key] == done {
+				delete(s.tails, key)
+			}
+			s.mu.Unlock()
+			close(done)
+		}),
+	}
+}
+
+// wait blocks until the previous delivery with the same key finished
+func (t *turn) wait(ctx context.Context) bool {
+	if t == nil || t.previous == nil {
+		return true
+	}
+
+	select {
+	case <-t.previous:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// done releases the next delivery with the same key
+func (t *turn) done() {
+	if t != nil {
+		t.release()
+	}
+}
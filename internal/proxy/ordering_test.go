@@ -0,0 +1,88 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestServeHTTP_Ordering(t *testing.T) {
+	tests := []struct {
+		name          string
+		orderingKey   *RequestKey
+		expectedOrder []string
+	}{
+		{
+			name:          "Deliveries with the same key wait for the previous one including its retries",
+			orderingKey:   &RequestKey{BodyField: "repo"},
+			expectedOrder: []string{"a1", "b1", "a1", "a2"},
+		},
+		{
+			name:          "Deliveries without an ordering key are concurrent",
+			expectedOrder: []string{"a1", "a2", "b1", "a1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			var mu sync.Mutex
+			var received []string
+
+			opts := DefaultOptions
+			opts.Client = &http.Client{
+				Transport: &dummyTransport{
+					transport: func(r *http.Request) (*http.Response, error) {
+						var payload struct {
+							ID string `json:"id"`
+						}
+
+						b, _ := io.ReadAll(r.Body)
+						_ = json.Unmarshal(b, &payload)
+
+						mu.Lock()
+						received = append(received, payload.ID)
+						attempts := len(received)
+						mu.Unlock()
+
+						// the first attempt of the first request is slow and fails
+						if attempts == 1 {
+							time.Sleep(50 * time.Millisecond)
+							return &http.Response{StatusCode: 500, Body: io.NopCloser(strings.NewReader("failed"))}, nil
+						}
+
+						return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+					},
+				},
+			}
+
+			proxy := New(opts)
+			g.Expect(proxy.RegisterOrUpdate(Receiver{
+				Path:         "/hook",
+				ResponseType: Async,
+				OrderingKey:  test.orderingKey,
+				Targets: []Target{
+					{Address: "target", Port: 8080, Retry: RetryPolicy{Attempts: 2}},
+				},
+			})).To(Succeed())
+
+			for _, body := range []string{`{"repo":"a","id":"a1"}`, `{"repo":"a","id":"a2"}`, `{"repo":"b","id":"b1"}`} {
+				req, _ := http.NewRequest("POST", "http://example.com/hook", strings.NewReader(body))
+				w := httptest.NewRecorder()
+				proxy.ServeHTTP(w, req)
+				g.Expect(w.Code).To(Equal(http.StatusAccepted))
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			proxy.Close()
+			g.Expect(received).To(Equal(test.expectedOrder))
+		})
+	}
+}
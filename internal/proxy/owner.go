@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// owner returns the namespace and name of the resource which registered the receiver
+func (r Receiver) owner() (string, string) {
+	if r.Object == nil {
+		return "", ""
+	}
+
+	return r.Object.Namespace, r.Object.Name
+}
+
+// ownedBy reports whether the receiver has been registered by the resource with the given namespace and name
+func (r Receiver) ownedBy(namespace, name string) bool {
+	return r.Object != nil && r.Object.Namespace == namespace && r.Object.Name == name
+}
+
+// receiverLogger returns a logger identifying the receiver by its owner and a fingerprint of its path
+func (h *HttpProxy) receiverLogger(receiver Receiver) logr.Logger {
+	log := h.log.WithValues("pathFingerprint", fingerprint(receiver.Path))
+	if receiver.Object == nil {
+		return log
+	}
+
+	return log.WithValues(
+		"receiver", fmt.Sprintf("%s/%s", receiver.Object.Namespace, receiver.Object.Name),
+		"receiverUID", receiver.Object.UID,
+		"generation", receiver.Generation,
+	)
+}
+
+// requestValues returns the log values of the incoming request, the request uri is only included if enabled
+func (h *HttpProxy) requestValues(r *http.Request) []any {
+	values := []any{"method", r.Method}
+	if h.logRequestURI {
+		return append(values, "request", r.RequestURI)
+	}
+
+	return values
+}
+
+// traceReceiver adds the owner of the receiver to the span of the incoming request
+func traceReceiver(ctx context.Context, receiver Receiver) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() || receiver.Object == nil {
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("webhook.receiver.namespace", receiver.Object.Namespace),
+		attribute.String("webhook.receiver.name", receiver.Object.Name),
+		attribute.String("webhook.receiver.uid", string(receiver.Object.UID)),
+		attribute.Int64("webhook.receiver.generation", receiver.Generation),
+	)
+}
@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestServeHTTP_LogsIdentifyOwner(t *testing.T) {
+	tests := []struct {
+		name          string
+		logRequestURI bool
+	}{
+		{
+			name: "Request uri is redacted by default",
+		},
+		{
+			name:          "Request uri is logged if enabled",
+			logRequestURI: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var (
+				mu   sync.Mutex
+				logs []string
+			)
+
+			opts := DefaultOptions
+			opts.LogRequestURI = test.logRequestURI
+			opts.Logger = funcr.New(func(prefix, args string) {
+				mu.Lock()
+				defer mu.Unlock()
+				logs = append(logs, args)
+			}, funcr.Options{})
+			opts.Client = &http.Client{
+				Transport: &dummyTransport{
+					transport: func(r *http.Request) (*http.Response, error) {
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+					},
+				},
+			}
+
+			proxy := New(opts)
+			g.Expect(proxy.RegisterOrUpdate(Receiver{
+				Path:         "/hooks/secret",
+				ResponseType: AwaitAllPreferSuccessful,
+				Object:       &corev1.ObjectReference{Namespace: "default", Name: "receiver", UID: "1234"},
+				Generation:   3,
+				Targets:      []Target{{Address: "target", Port: 8080, ServiceName: "podinfo", ServiceNamespace: "default"}},
+			})).To(Succeed())
+
+			w := httptest.NewRecorder()
+			proxy.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/hooks/secret?token=foo", strings.NewReader("body")))
+			g.Expect(w.Code).To(Equal(http.StatusOK))
+
+			mu.Lock()
+			defer mu.Unlock()
+			output := strings.Join(logs, "\n")
+
+			g.Expect(output).To(ContainSubstring(`"receiver"="default/receiver"`))
+			g.Expect(output).To(ContainSubstring(`"receiverUID"="1234"`))
+			g.Expect(output).To(ContainSubstring(`"generation"=3`))
+			g.Expect(output).To(ContainSubstring(fingerprint("/hooks/secret")))
+
+			if test.logRequestURI {
+				g.Expect(output).To(ContainSubstring("/hooks/secret?token=foo"))
+			} else {
+				g.Expect(output).NotTo(ContainSubstring("secret"))
+			}
+		})
+	}
+}
+
+func TestUnregisterOwner(t *testing.T) {
+	g := NewWithT(t)
+	proxy := New(DefaultOptions)
+
+	for _, receiver := range []Receiver{
+		{Path: "/a", Object: &corev1.ObjectReference{Namespace: "default", Name: "a"}},
+		{Path: "/a-old", Object: &corev1.ObjectReference{Namespace: "default", Name: "a"}},
+		{Path: "/b", Object: &corev1.ObjectReference{Namespace: "default", Name: "b"}},
+		{Path: "/c"},
+	} {
+		g.Expect(proxy.RegisterOrUpdate(receiver)).To(Succeed())
+	}
+
+	g.Expect(proxy.UnregisterOwner("default", "a")).To(Succeed())
+	g.Expect(proxy.IsRegistered("/a")).To(BeFalse())
+	g.Expect(proxy.IsRegistered("/a-old")).To(BeFalse())
+	g.Expect(proxy.IsRegistered("/b")).To(BeTrue())
+	g.Expect(proxy.IsRegistered("/c")).To(BeTrue())
+}
+
+func TestTraceReceiver(t *testing.T) {
+	g := NewWithT(t)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	ctx, span := provider.Tracer("test").Start(t.Context(), "request")
+	traceReceiver(ctx, Receiver{
+		Object:     &corev1.ObjectReference{Namespace: "default", Name: "receiver", UID: "1234"},
+		Generation: 3,
+	})
+	span.End()
+
+	g.Expect(recorder.Ended()).To(HaveLen(1))
+	g.Expect(recorder.Ended()[0].Attributes()).To(ConsistOf(
+		attribute.String("webhook.receiver.namespace", "default"),
+		attribute.String("webhook.receiver.name", "receiver"),
+		attribute.String("webhook.receiver.uid", "1234"),
+		attribute.Int64("webhook.receiver.generation", 3),
+	))
+}
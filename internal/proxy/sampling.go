@@ -0,0 +1,32 @@
+package proxy
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+)
+
+// SamplingPolicy forwards only a percentage of the requests to a target
+type SamplingPolicy struct {
+	// Percentage of the requests forwarded to the target, from 0 to 100
+	Percentage int
+	// Key samples requests deterministically by a hash of the key instead of randomly,
+	// requests with the same key are either always or never forwarded
+	Key *RequestKey
+}
+
+// sample reports whether the request is forwarded to the target
+func (p *SamplingPolicy) sample(header http.Header, body []byte) bool {
+	switch {
+	case p == nil || p.Percentage >= 100:
+		return true
+	case p.Percentage <= 0:
+		return false
+	case p.Key == nil:
+		return rand.IntN(100) < p.Percentage
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(p.Key.extract(header, body)))
+	return int(hash.Sum32()%100) < p.Percentage
+}
@@ -0,0 +1,167 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSamplingPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      *SamplingPolicy
+		expectedMin int
+		expectedMax int
+	}{
+		{
+			name:        "Without a policy all requests are sampled",
+			expectedMin: 1000,
+			expectedMax: 1000,
+		},
+		{
+			name:        "Samples no requests",
+			policy:      &SamplingPolicy{Percentage: 0},
+			expectedMin: 0,
+			expectedMax: 0,
+		},
+		{
+			name:        "Samples all requests",
+			policy:      &SamplingPolicy{Percentage: 100},
+			expectedMin: 1000,
+			expectedMax: 1000,
+		},
+		{
+			name:        "Samples a percentage of the requests randomly",
+			policy:      &SamplingPolicy{Percentage: 50},
+			expectedMin: 400,
+			expectedMax: 600,
+		},
+		{
+			name:        "Samples a percentage of the requests by key",
+			policy:      &SamplingPolicy{Percentage: 50, Key: &RequestKey{BodyField: "id"}},
+			expectedMin: 400,
+			expectedMax: 600,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var sampled int
+			for i := range 1000 {
+				if test.policy.sample(http.Header{}, fmt.Appendf(nil, `{"id":%d}`, i)) {
+					sampled++
+				}
+			}
+
+			g.Expect(sampled).To(BeNumerically(">=", test.expectedMin))
+			g.Expect(sampled).To(BeNumerically("<=", test.expectedMax))
+		})
+	}
+}
+
+func TestSamplingPolicyIsDeterministicByKey(t *testing.T) {
+	g := NewWithT(t)
+	policy := &SamplingPolicy{Percentage: 50, Key: &RequestKey{Header: "X-Repository"}}
+
+	for i := range 100 {
+		header := http.Header{"X-Repository": []string{fmt.Sprintf("org/repo-%d", i)}}
+		expected := policy.sample(header, nil)
+
+		for range 10 {
+			g.Expect(policy.sample(header, nil)).To(Equal(expected), "key %d", i)
+		}
+	}
+}
+
+func TestServeHTTP_Sampling(t *testing.T) {
+	tests := []struct {
+		name             string
+		targets          []Target
+		expectedCode     int
+		expectedServices []string
+	}{
+		{
+			name: "Sampled out targets are excluded from the report",
+			targets: []Target{
+				{Address: "target-a", Port: 8080, ServiceName: "a"},
+				{Address: "target-b", Port: 8080, ServiceName: "b", Sampling: &SamplingPolicy{Percentage: 0}},
+			},
+			expectedCode:     http.StatusOK,
+			expectedServices: []string{"a"},
+		},
+		{
+			name: "Sampled targets are included in the report",
+			targets: []Target{
+				{Address: "target-a", Port: 8080, ServiceName: "a"},
+				{Address: "target-b", Port: 8080, ServiceName: "b", Sampling: &SamplingPolicy{Percentage: 100}},
+			},
+			expectedCode:     http.StatusOK,
+			expectedServices: []string{"a", "b"},
+		},
+		{
+			name: "Requests are accepted if all targets are sampled out",
+			targets: []Target{
+				{Address: "target-a", Port: 8080, ServiceName: "a", Sampling: &SamplingPolicy{Percentage: 0}},
+			},
+			expectedCode: http.StatusAccepted,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			var mu sync.Mutex
+			var received []string
+
+			opts := DefaultOptions
+			opts.Client = &http.Client{
+				Transport: &dummyTransport{
+					transport: func(r *http.Request) (*http.Response, error) {
+						mu.Lock()
+						received = append(received, r.URL.Host)
+						mu.Unlock()
+
+						return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+					},
+				},
+			}
+
+			proxy := New(opts)
+			g.Expect(proxy.RegisterOrUpdate(Receiver{
+				Path:         "/hook",
+				ResponseType: AwaitAllReport,
+				Targets:      test.targets,
+			})).To(Succeed())
+
+			req, _ := http.NewRequest("POST", "http://example.com/hook", strings.NewReader("body"))
+			w := httptest.NewRecorder()
+			proxy.ServeHTTP(w, req)
+			proxy.Close()
+
+			g.Expect(w.Code).To(Equal(test.expectedCode))
+			g.Expect(received).To(HaveLen(len(test.expectedServices)))
+			if test.expectedCode != http.StatusOK {
+				return
+			}
+
+			var report ReportResponse
+			g.Expect(json.Unmarshal(w.Body.Bytes(), &report)).To(Succeed())
+
+			var services []string
+			for _, target := range report.Targets {
+				services = append(services, target.Service)
+			}
+
+			g.Expect(services).To(ConsistOf(test.expectedServices))
+		})
+	}
+}
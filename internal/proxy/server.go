@@ -0,0 +1,204 @@
+package proxy
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+var ErrShuttingDown = errors.New("http server is shutting down")
+
+const (
+	// LivenessPath is answered by the server itself and used by the liveness check
+	LivenessPath = "/livez"
+
+	DefaultLivenessTimeout = 5 * time.Second
+)
+
+// Server runs the http server of the proxy as a manager.Runnable.
+// Once the manager stops it fails the readiness check, keeps accepting requests for ShutdownDelay
+// until endpoints and load balancers removed the pod and then waits up to ShutdownTimeout for in-flight deliveries to finish.
+type Server struct {
+	server          *http.Server
+	proxy           *HttpProxy
+	shutdownDelay   time.Duration
+	shutdownTimeout time.Duration
+	livenessTimeout time.Duration
+	log             logr.Logger
+	shuttingDown    atomic.Bool
+	addr            atomic.Pointer[string]
+	client          *http.Client
+	tls             bool
+}
+
+type ServerOptions struct {
+	Logger logr.Logger
+	// ShutdownDelay is the time requests are still accepted after the readiness check failed
+	ShutdownDelay   time.Duration
+	ShutdownTimeout time.Duration
+	LivenessTimeout time.Duration
+}
+
+// NewServer wraps the handler of the http server to answer requests to LivenessPath.
+// If the tls config requires client certificates they are verified during the handshake but enforced by the handler,
+// this allows the liveness check to reach LivenessPath without a client certificate.
+func NewServer(server *http.Server, proxy *HttpProxy, opts ServerOptions) *Server {
+	s := &Server{
+		server:          server,
+		proxy:           proxy,
+		shutdownDelay:   opts.ShutdownDelay,
+		shutdownTimeout: opts.ShutdownTimeout,
+		livenessTimeout: cmp.Or(opts.LivenessTimeout, DefaultLivenessTimeout),
+		log:             opts.Logger,
+		tls:             server.TLSConfig != nil,
+		client: &http.Client{
+			// Every check uses a new connection to detect a server which does not accept connections anymore
+			Transport: &http.Transport{
+				DisableKeepAlives: true,
+				TLSClientConfig: &tls.Config{
+					MinVersion: tls.VersionTLS12,
+					// #nosec G402 -- the server probes itself, only the responsiveness is verified
+					InsecureSkipVerify: true,
+				},
+			},
+		},
+	}
+
+	requireClientCert := false
+	if server.TLSConfig != nil && server.TLSConfig.ClientAuth == tls.RequireAndVerifyClientCert {
+		server.TLSConfig = server.TLSConfig.Clone()
+		server.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		requireClientCert = true
+	}
+
+	handler := server.Handler
+	server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == LivenessPath {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		if requireClientCert && (r.TLS == nil || len(r.TLS.PeerCertificates) == 0) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+
+	return s
+}
+
+// Start serves http requests until the context is done and shuts the server down gracefully
+func (s *Server) Start(ctx context.Context) error {
+	l, err := net.Listen("tcp", cmp.Or(s.server.Addr, ":http"))
+	if err != nil {
+		return err
+	}
+
+	addr := dialAddr(l.Addr())
+	s.addr.Store(&addr)
+
+	errs := make(chan error, 1)
+	go func() {
+		s.log.Info("starting http server", "addr", l.Addr().String(), "tls", s.tls)
+		if s.tls {
+			errs <- s.server.ServeTLS(l, "", "")
+			return
+		}
+
+		errs <- s.server.Serve(l)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.shuttingDown.Store(true)
+	s.log.Info("shutting down http server", "delay", s.shutdownDelay, "timeout", s.shutdownTimeout)
+
+	// Endpoints and load balancers take a while to observe the failed readiness check
+	time.Sleep(s.shutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	if err := s.server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shutdown http server: %w", err)
+	}
+
+	if err := s.proxy.Drain(shutdownCtx); err != nil {
+		return err
+	}
+
+	s.log.Info("http server stopped, all deliveries finished")
+	return nil
+}
+
+// NeedLeaderElection returns false since every replica serves webhooks
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// ReadyzCheck fails once the server is shutting down
+func (s *Server) ReadyzCheck(_ *http.Request) error {
+	if s.shuttingDown.Load() {
+		return ErrShuttingDown
+	}
+
+	return nil
+}
+
+// LivezCheck sends a request to the http server and fails if it is not answered within the liveness timeout.
+// It passes while the server is not yet started or shutting down.
+func (s *Server) LivezCheck(req *http.Request) error {
+	addr := s.addr.Load()
+	if addr == nil || s.shuttingDown.Load() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), s.livenessTimeout)
+	defer cancel()
+
+	scheme := "http"
+	if s.tls {
+		scheme = "https"
+	}
+
+	probe, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s%s", scheme, *addr, LivenessPath), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.client.Do(probe)
+	if err != nil {
+		return fmt.Errorf("http server is not responding: %w", err)
+	}
+
+	closeBody(res)
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("http server responded with unexpected status code %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// dialAddr returns an address to reach a listener locally, unspecified ips are replaced by the loopback address
+func dialAddr(addr net.Addr) string {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok || !tcpAddr.IP.IsUnspecified() {
+		return addr.String()
+	}
+
+	return net.JoinHostPort("localhost", fmt.Sprint(tcpAddr.Port))
+}
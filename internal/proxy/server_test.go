@@ -0,0 +1,218 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+)
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		_ = l.Close()
+	}()
+
+	return l.Addr().String()
+}
+
+func TestServer_GracefulShutdown(t *testing.T) {
+	tests := []struct {
+		name            string
+		shutdownTimeout time.Duration
+		release         bool
+		expectedErr     string
+	}{
+		{
+			name:            "Waits for in-flight deliveries",
+			shutdownTimeout: 5 * time.Second,
+			release:         true,
+		},
+		{
+			name:            "Gives up once the shutdown timeout is reached",
+			shutdownTimeout: 50 * time.Millisecond,
+			expectedErr:     "1 deliveries still in flight: context deadline exceeded",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			release := make(chan struct{})
+			delivered := make(chan struct{}, 1)
+
+			opts := DefaultOptions
+			opts.Client = &http.Client{
+				Transport: &dummyTransport{
+					transport: func(r *http.Request) (*http.Response, error) {
+						<-release
+						delivered <- struct{}{}
+						return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+					},
+				},
+			}
+
+			proxy := New(opts)
+			g.Expect(proxy.RegisterOrUpdate(Receiver{
+				Path:         "/hook",
+				ResponseType: Async,
+				Targets:      []Target{{Address: "target", Port: 8080}},
+			})).To(Succeed())
+
+			addr := freeAddr(t)
+			server := NewServer(&http.Server{Addr: addr, Handler: proxy}, proxy, ServerOptions{
+				Logger:          logr.Discard(),
+				ShutdownTimeout: test.shutdownTimeout,
+			})
+
+			g.Expect(server.NeedLeaderElection()).To(BeFalse())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			stopped := make(chan error, 1)
+			go func() {
+				stopped <- server.Start(ctx)
+			}()
+
+			var res *http.Response
+			g.Eventually(func() error {
+				var err error
+				res, err = http.Post(fmt.Sprintf("http://%s/hook", addr), "text/plain", strings.NewReader("body"))
+				return err
+			}).Should(Succeed())
+			g.Expect(res.StatusCode).To(Equal(http.StatusAccepted))
+			g.Expect(server.ReadyzCheck(nil)).To(Succeed())
+
+			cancel()
+			g.Eventually(func() error {
+				return server.ReadyzCheck(nil)
+			}).Should(MatchError(ErrShuttingDown))
+
+			if test.release {
+				g.Consistently(stopped, 100*time.Millisecond).ShouldNot(Receive(), "server must wait for the in-flight delivery")
+				close(release)
+				g.Eventually(stopped).Should(Receive(BeNil()))
+				g.Expect(delivered).To(Receive())
+				return
+			}
+
+			var err error
+			g.Eventually(stopped).Should(Receive(&err))
+			g.Expect(err).To(MatchError(test.expectedErr))
+			close(release)
+			proxy.Close()
+		})
+	}
+}
+
+func TestServer_ShutdownDelay(t *testing.T) {
+	g := NewWithT(t)
+
+	opts := DefaultOptions
+	opts.Client = &http.Client{
+		Transport: &dummyTransport{
+			transport: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+			},
+		},
+	}
+
+	proxy := New(opts)
+	g.Expect(proxy.RegisterOrUpdate(Receiver{
+		Path:         "/hook",
+		ResponseType: Async,
+		Targets:      []Target{{Address: "target", Port: 8080}},
+	})).To(Succeed())
+
+	addr := freeAddr(t)
+	server := NewServer(&http.Server{Addr: addr, Handler: proxy}, proxy, ServerOptions{
+		Logger:          logr.Discard(),
+		ShutdownDelay:   500 * time.Millisecond,
+		ShutdownTimeout: time.Second,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Start(ctx)
+	}()
+
+	g.Eventually(func() *string {
+		return server.addr.Load()
+	}).ShouldNot(BeNil())
+
+	cancel()
+	g.Eventually(func() error {
+		return server.ReadyzCheck(nil)
+	}).Should(MatchError(ErrShuttingDown))
+
+	res, err := http.Post(fmt.Sprintf("http://%s/hook", addr), "text/plain", strings.NewReader("body"))
+	g.Expect(err).NotTo(HaveOccurred(), "requests are accepted during the shutdown delay")
+	g.Expect(res.StatusCode).To(Equal(http.StatusAccepted))
+	closeBody(res)
+
+	g.Eventually(stopped, 2*time.Second).Should(Receive(BeNil()))
+}
+
+func TestServer_LivezCheck(t *testing.T) {
+	g := NewWithT(t)
+	proxy := New(DefaultOptions)
+	server := NewServer(&http.Server{Addr: "127.0.0.1:0", Handler: proxy}, proxy, ServerOptions{
+		Logger:          logr.Discard(),
+		ShutdownTimeout: time.Second,
+		LivenessTimeout: 100 * time.Millisecond,
+	})
+
+	req, err := http.NewRequest(http.MethodGet, "/healthz", nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(server.LivezCheck(req)).To(Succeed(), "passes before the server is started")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Start(ctx)
+	}()
+
+	g.Eventually(func() *string {
+		return server.addr.Load()
+	}).ShouldNot(BeNil())
+	g.Expect(server.LivezCheck(req)).To(Succeed())
+
+	cancel()
+	g.Eventually(stopped).Should(Receive(BeNil()))
+	g.Expect(server.LivezCheck(req)).To(Succeed(), "passes while shutting down")
+}
+
+func TestServer_LivezCheckWedged(t *testing.T) {
+	g := NewWithT(t)
+
+	// A listener which never accepts connections
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer func() {
+		_ = l.Close()
+	}()
+
+	proxy := New(DefaultOptions)
+	server := NewServer(&http.Server{Handler: proxy}, proxy, ServerOptions{
+		Logger:          logr.Discard(),
+		LivenessTimeout: 100 * time.Millisecond,
+	})
+
+	addr := l.Addr().String()
+	server.addr.Store(&addr)
+
+	req, err := http.NewRequest(http.MethodGet, "/healthz", nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(server.LivezCheck(req)).To(MatchError(ContainSubstring("http server is not responding")))
+}
@@ -0,0 +1,137 @@
+package proxy
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// spool is an in-process pipe with a non-blocking writer.
+// Data is buffered in memory up to limit bytes, anything beyond is spilled to a temporary file
+// until the reader caught up again. This allows streaming a request body to multiple targets
+// while slow targets neither block the others nor grow the memory usage.
+type spool struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	limit int64
+	dir   string
+
+	mem []byte
+
+	file   *os.File
+	fileW  int64
+	fileR  int64
+	closed bool
+	err    error
+
+	readerClosed bool
+}
+
+func newSpool(limit int64, dir string) *spool {
+	s := &spool{
+		limit: limit,
+		dir:   dir,
+	}
+
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// Write never blocks on the reader, data is discarded once the reader is closed
+func (s *spool) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.cond.Broadcast()
+
+	if s.readerClosed {
+		return len(p), nil
+	}
+
+	if s.fileR == s.fileW && int64(len(s.mem)+len(p)) <= s.limit {
+		s.mem = append(s.mem, p...)
+		return len(p), nil
+	}
+
+	if s.file == nil {
+		f, err := os.CreateTemp(s.dir, "webhook-spool-")
+		if err != nil {
+			return 0, err
+		}
+
+		s.file = f
+	}
+
+	n, err := s.file.WriteAt(p, s.fileW)
+	s.fileW += int64(n)
+	return n, err
+}
+
+// CloseWrite signals the reader that no more data follows.
+// If err is not nil the reader receives it once all buffered data is consumed.
+func (s *spool) CloseWrite(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	s.err = err
+	s.cond.Broadcast()
+}
+
+// Read blocks until data is available or the writer is closed
+func (s *spool) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		switch {
+		case s.readerClosed:
+			return 0, os.ErrClosed
+		case len(s.mem) > 0:
+			n := copy(p, s.mem)
+			s.mem = s.mem[n:]
+			if len(s.mem) == 0 {
+				s.mem = nil
+			}
+
+			return n, nil
+		case s.fileR < s.fileW:
+			n, err := s.file.ReadAt(p[:min(int64(len(p)), s.fileW-s.fileR)], s.fileR)
+			s.fileR += int64(n)
+			if s.fileR == s.fileW {
+				// the reader caught up, the spill file can be reused from the beginning
+				s.fileR, s.fileW = 0, 0
+			}
+
+			if err == io.EOF {
+				err = nil
+			}
+
+			return n, err
+		case s.closed && s.err != nil:
+			return 0, s.err
+		case s.closed:
+			return 0, io.EOF
+		}
+
+		s.cond.Wait()
+	}
+}
+
+// Close releases the buffered data and removes the spill file
+func (s *spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.readerClosed = true
+	s.mem = nil
+	s.cond.Broadcast()
+
+	if s.file == nil {
+		return nil
+	}
+
+	_ = s.file.Close()
+	err := os.Remove(s.file.Name())
+	s.file = nil
+	return err
+}
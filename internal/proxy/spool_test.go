@@ -0,0 +1,98 @@
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSpool_Memory(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	s := newSpool(1024, dir)
+
+	_, err := s.Write([]byte("hello "))
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = s.Write([]byte("world"))
+	g.Expect(err).NotTo(HaveOccurred())
+	s.CloseWrite(nil)
+
+	b, err := io.ReadAll(s)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(b)).To(Equal("hello world"))
+	g.Expect(s.file).To(BeNil(), "data within the limit must not be spilled")
+	g.Expect(s.Close()).To(Succeed())
+}
+
+func TestSpool_SpillToDisk(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	s := newSpool(4, dir)
+
+	payload := bytes.Repeat([]byte("0123456789"), 100)
+	for i := 0; i < len(payload); i += 7 {
+		_, err := s.Write(payload[i:min(i+7, len(payload))])
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	g.Expect(int64(len(s.mem))).To(BeNumerically("<=", 4))
+	entries, _ := os.ReadDir(dir)
+	g.Expect(entries).To(HaveLen(1))
+
+	s.CloseWrite(nil)
+	b, err := io.ReadAll(s)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(b).To(Equal(payload))
+
+	g.Expect(s.Close()).To(Succeed())
+	entries, _ = os.ReadDir(dir)
+	g.Expect(entries).To(BeEmpty(), "spill file must be removed once the reader is closed")
+}
+
+func TestSpool_ConcurrentReader(t *testing.T) {
+	g := NewWithT(t)
+	s := newSpool(16, t.TempDir())
+	payload := bytes.Repeat([]byte("abcdefgh"), 4096)
+
+	done := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(s)
+		done <- b
+	}()
+
+	for i := 0; i < len(payload); i += 100 {
+		_, err := s.Write(payload[i:min(i+100, len(payload))])
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	s.CloseWrite(nil)
+	g.Expect(<-done).To(Equal(payload))
+	g.Expect(s.Close()).To(Succeed())
+}
+
+func TestSpool_WriteError(t *testing.T) {
+	g := NewWithT(t)
+	s := newSpool(1024, t.TempDir())
+
+	_, _ = s.Write([]byte("partial"))
+	s.CloseWrite(errors.New("client disconnected"))
+
+	b, err := io.ReadAll(s)
+	g.Expect(string(b)).To(Equal("partial"))
+	g.Expect(err).To(MatchError("client disconnected"))
+}
+
+func TestSpool_WriteAfterClose(t *testing.T) {
+	g := NewWithT(t)
+	s := newSpool(0, t.TempDir())
+
+	g.Expect(s.Close()).To(Succeed())
+	n, err := s.Write([]byte("discarded"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(n).To(Equal(9))
+	g.Expect(s.file).To(BeNil())
+}
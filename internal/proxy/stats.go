@@ -0,0 +1,237 @@
+package proxy
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DefaultStatsWindow is the rolling window delivery counts are calculated over
+const DefaultStatsWindow = 5 * time.Minute
+
+// statsBuckets is the number of buckets the rolling window is split into
+const statsBuckets = 10
+
+// ReceiverStats are the delivery statistics of a receiver
+type ReceiverStats struct {
+	LastRequestTime time.Time
+	Successful      int64
+	Failed          int64
+	Targets         []TargetStats
+}
+
+// TargetStats are the delivery statistics of a single target.
+// Successful and Failed are counted over the stats window.
+type TargetStats struct {
+	Service         string
+	Namespace       string
+	Port            int32
+	LastRequestTime time.Time
+	LastSuccessTime time.Time
+	LastFailureTime time.Time
+	LastStatusCode  int
+	Successful      int64
+	Failed          int64
+	// CircuitState is the state of the circuit breaker, empty if the target has none
+	CircuitState CircuitState
+}
+
+type statsBucket struct {
+	epoch      int64
+	successful int64
+	failed     int64
+}
+
+type targetStatsKey struct {
+	service   string
+	namespace string
+	port      int32
+}
+
+type targetStats struct {
+	lastRequestTime time.Time
+	lastSuccessTime time.Time
+	lastFailureTime time.Time
+	lastStatusCode  int
+	buckets         [statsBuckets]statsBucket
+}
+
+// deliveryStats collects the delivery statistics per owner of a receiver
+type deliveryStats struct {
+	mu      sync.Mutex
+	window  time.Duration
+	owners  map[types.UID]map[targetStatsKey]*targetStats
+	nowFunc func() time.Time
+}
+
+func (s *deliveryStats) now() time.Time {
+	if s.nowFunc != nil {
+		return s.nowFunc()
+	}
+
+	return time.Now()
+}
+
+// epoch returns the number of the bucket containing t, counted since the unix epoch
+func (s *deliveryStats) epoch(t time.Time) int64 {
+	return t.UnixNano() / int64(max(s.window/statsBuckets, 1))
+}
+
+func (s *deliveryStats) record(receiver Receiver, result targetResult) {
+	if receiver.Object == nil {
+		return
+	}
+
+	now := s.now()
+	key := targetStatsKey{
+		service:   result.target.ServiceName,
+		namespace: result.target.ServiceNamespace,
+		port:      result.target.Port,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.owners == nil {
+		s.owners = make(map[types.UID]map[targetStatsKey]*targetStats)
+	}
+
+	targets, ok := s.owners[receiver.Object.UID]
+	if !ok {
+		targets = make(map[targetStatsKey]*targetStats)
+		s.owners[receiver.Object.UID] = targets
+	}
+
+	stats, ok := targets[key]
+	if !ok {
+		stats = &targetStats{}
+		targets[key] = stats
+	}
+
+	epoch := s.epoch(now)
+	bucket := &stats.buckets[epoch%statsBuckets]
+	if bucket.epoch != epoch {
+		*bucket = statsBucket{epoch: epoch}
+	}
+
+	stats.lastRequestTime = now
+	stats.lastStatusCode = result.response.StatusCode
+
+	switch result.outcome {
+	case outcomeSuccess:
+		stats.lastSuccessTime = now
+		bucket.successful++
+	case outcomeFailure:
+		stats.lastFailureTime = now
+		bucket.failed++
+	}
+}
+
+func (s *deliveryStats) get(owner types.UID) (ReceiverStats, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	targets, ok := s.owners[owner]
+	if !ok {
+		return ReceiverStats{}, false
+	}
+
+	oldest := s.epoch(s.now()) - statsBuckets
+	var stats ReceiverStats
+
+	for key, target := range targets {
+		targetStats := TargetStats{
+			Service:         key.service,
+			Namespace:       key.namespace,
+			Port:            key.port,
+			LastRequestTime: target.lastRequestTime,
+			LastSuccessTime: target.lastSuccessTime,
+			LastFailureTime: target.lastFailureTime,
+			LastStatusCode:  target.lastStatusCode,
+		}
+
+		for _, bucket := range target.buckets {
+			if bucket.epoch > oldest {
+				targetStats.Successful += bucket.successful
+				targetStats.Failed += bucket.failed
+			}
+		}
+
+		if target.lastRequestTime.After(stats.LastRequestTime) {
+			stats.LastRequestTime = target.lastRequestTime
+		}
+
+		stats.Successful += targetStats.Successful
+		stats.Failed += targetStats.Failed
+		stats.Targets = append(stats.Targets, targetStats)
+	}
+
+	slices.SortFunc(stats.Targets, func(a, b TargetStats) int {
+		return cmp.Or(
+			cmp.Compare(a.Namespace, b.Namespace),
+			cmp.Compare(a.Service, b.Service),
+			cmp.Compare(a.Port, b.Port),
+		)
+	})
+
+	return stats, true
+}
+
+// uids returns the uids of all owners with statistics
+func (s *deliveryStats) uids() []types.UID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Collect(maps.Keys(s.owners))
+}
+
+func (s *deliveryStats) delete(owner types.UID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.owners, owner)
+}
+
+// DeliveryStats returns the delivery statistics of all receivers by the uid of the resource which registered them.
+// Resources without any delivery yet are omitted.
+func (h *HttpProxy) DeliveryStats() map[types.UID]ReceiverStats {
+	stats := make(map[types.UID]ReceiverStats)
+	for _, owner := range h.stats.uids() {
+		if ownerStats, ok := h.stats.get(owner); ok {
+			stats[owner] = ownerStats
+		}
+	}
+
+	for path, receiver := range h.snapshot() {
+		if receiver.Object == nil {
+			continue
+		}
+
+		ownerStats, ok := stats[receiver.Object.UID]
+		if !ok {
+			continue
+		}
+
+		for _, target := range receiver.Targets {
+			state := h.breakers.state(path, target)
+			if state == "" {
+				continue
+			}
+
+			for i := range ownerStats.Targets {
+				if ownerStats.Targets[i].Service == target.ServiceName && ownerStats.Targets[i].Namespace == target.ServiceNamespace && ownerStats.Targets[i].Port == target.Port {
+					ownerStats.Targets[i].CircuitState = state
+				}
+			}
+		}
+	}
+
+	return stats
+}
+
+// StatsWindow returns the rolling window delivery counts are calculated over
+func (h *HttpProxy) StatsWindow() time.Duration {
+	return h.stats.window
+}
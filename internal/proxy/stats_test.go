@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestDeliveryStats(t *testing.T) {
+	g := NewWithT(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	stats := deliveryStats{
+		window: 5 * time.Minute,
+		nowFunc: func() time.Time {
+			return now
+		},
+	}
+
+	receiver := Receiver{Object: &corev1.ObjectReference{Namespace: "default", Name: "receiver", UID: "1234"}}
+	podinfo := Target{ServiceName: "podinfo", ServiceNamespace: "default", Port: 9898}
+	echo := Target{ServiceName: "echo", ServiceNamespace: "default", Port: 80}
+
+	record := func(target Target, outcome outcome, statusCode int) {
+		stats.record(receiver, targetResult{
+			target:   target,
+			outcome:  outcome,
+			response: &http.Response{StatusCode: statusCode},
+		})
+	}
+
+	_, ok := stats.get("1234")
+	g.Expect(ok).To(BeFalse(), "no stats before the first delivery")
+
+	start := now
+	record(podinfo, outcomeSuccess, http.StatusOK)
+	record(echo, outcomeSuccess, http.StatusOK)
+
+	now = start.Add(2 * time.Minute)
+	record(podinfo, outcomeFailure, http.StatusServiceUnavailable)
+	record(podinfo, outcomeOther, http.StatusFound)
+
+	stats.record(Receiver{}, targetResult{target: podinfo, outcome: outcomeFailure, response: &http.Response{StatusCode: 500}})
+
+	result, ok := stats.get("1234")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(result).To(Equal(ReceiverStats{
+		LastRequestTime: start.Add(2 * time.Minute),
+		Successful:      2,
+		Failed:          1,
+		Targets: []TargetStats{
+			{
+				Service:         "echo",
+				Namespace:       "default",
+				Port:            80,
+				LastRequestTime: start,
+				LastSuccessTime: start,
+				LastStatusCode:  http.StatusOK,
+				Successful:      1,
+			},
+			{
+				Service:         "podinfo",
+				Namespace:       "default",
+				Port:            9898,
+				LastRequestTime: start.Add(2 * time.Minute),
+				LastSuccessTime: start,
+				LastFailureTime: start.Add(2 * time.Minute),
+				LastStatusCode:  http.StatusFound,
+				Successful:      1,
+				Failed:          1,
+			},
+		},
+	}))
+
+	now = start.Add(6 * time.Minute)
+	result, _ = stats.get("1234")
+	g.Expect(result.Successful).To(BeZero(), "deliveries older than the window are not counted")
+	g.Expect(result.Failed).To(Equal(int64(1)))
+	g.Expect(result.LastRequestTime).To(Equal(start.Add(2*time.Minute)), "last times are kept")
+
+	now = start.Add(12 * time.Minute)
+	record(podinfo, outcomeSuccess, http.StatusOK)
+	result, _ = stats.get("1234")
+	g.Expect(result.Successful).To(Equal(int64(1)), "reused buckets are reset")
+	g.Expect(result.Failed).To(BeZero())
+
+	stats.delete("1234")
+	_, ok = stats.get("1234")
+	g.Expect(ok).To(BeFalse())
+}
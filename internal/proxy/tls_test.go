@@ -0,0 +1,203 @@
+package proxy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	tlsCert tls.Certificate
+	pem     []byte
+}
+
+func newTestCert(t *testing.T, template *x509.Certificate, parent *testCert) testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signerCert, signerKey := template, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testCert{
+		cert:    cert,
+		key:     key,
+		tlsCert: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key},
+		pem:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+func newTestCA(t *testing.T) testCert {
+	return newTestCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil)
+}
+
+func newTestLeaf(t *testing.T, ca testCert, usage x509.ExtKeyUsage) testCert {
+	return newTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}, &ca)
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalid, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		clientCAFile string
+		expectedErr  string
+	}{
+		{
+			name: "Client certificates are optional without client ca",
+		},
+		{
+			name:         "Fails if the client ca does not exist",
+			clientCAFile: filepath.Join(dir, "missing.pem"),
+			expectedErr:  "failed to read client ca",
+		},
+		{
+			name:         "Fails if the client ca contains no certificates",
+			clientCAFile: invalid,
+			expectedErr:  "no valid certificates found in client ca",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			config, err := NewTLSConfig(nil, test.clientCAFile)
+			if test.expectedErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(test.expectedErr)))
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(config.ClientAuth).To(Equal(tls.NoClientCert))
+			g.Expect(config.MinVersion).To(Equal(uint16(tls.VersionTLS12)))
+		})
+	}
+}
+
+func TestServer_TLS(t *testing.T) {
+	g := NewWithT(t)
+	ca := newTestCA(t)
+	serverCert := newTestLeaf(t, ca, x509.ExtKeyUsageServerAuth)
+	clientCert := newTestLeaf(t, ca, x509.ExtKeyUsageClientAuth)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	g.Expect(os.WriteFile(caFile, ca.pem, 0600)).To(Succeed())
+
+	config, err := NewTLSConfig(func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return &serverCert.tlsCert, nil
+	}, caFile)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(config.ClientAuth).To(Equal(tls.RequireAndVerifyClientCert))
+
+	proxy := New(DefaultOptions)
+	addr := freeAddr(t)
+	server := NewServer(&http.Server{Addr: addr, Handler: proxy, TLSConfig: config}, proxy, ServerOptions{
+		Logger:          logr.Discard(),
+		ShutdownTimeout: time.Second,
+		LivenessTimeout: time.Second,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Start(ctx)
+	}()
+
+	defer func() {
+		cancel()
+		g.Eventually(stopped).Should(Receive(BeNil()))
+	}()
+
+	req, err := http.NewRequest(http.MethodGet, "/healthz", nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Eventually(func() error {
+		if server.addr.Load() == nil {
+			return fmt.Errorf("not started")
+		}
+
+		return server.LivezCheck(req)
+	}).Should(Succeed())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	url := fmt.Sprintf("https://localhost:%s%s", addr[len("127.0.0.1:"):], LivenessPath)
+
+	withoutCert := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12},
+	}}
+
+	res, err := withoutCert.Get(url)
+	g.Expect(err).NotTo(HaveOccurred())
+	closeBody(res)
+	g.Expect(res.StatusCode).To(Equal(http.StatusOK), "the liveness path does not require a client certificate")
+
+	res, err = withoutCert.Get(strings.Replace(url, LivenessPath, "/hook", 1))
+	g.Expect(err).NotTo(HaveOccurred())
+	closeBody(res)
+	g.Expect(res.StatusCode).To(Equal(http.StatusUnauthorized), "clients without certificate are rejected")
+
+	withCert := &http.Client{Transport: &http.Transport{
+		ForceAttemptHTTP2: true,
+		TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: []tls.Certificate{clientCert.tlsCert},
+			MinVersion:   tls.VersionTLS12,
+		},
+	}}
+	res, err = withCert.Get(strings.Replace(url, LivenessPath, "/hook", 1))
+	g.Expect(err).NotTo(HaveOccurred())
+	closeBody(res)
+	g.Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable), "requests with client certificate reach the proxy")
+	g.Expect(res.ProtoMajor).To(Equal(2), "http2 is negotiated by default")
+}
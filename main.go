@@ -18,12 +18,14 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
-	"sync"
+	"strings"
 	"time"
 
+	infrav1 "github.com/DoodleScheduling/webhook-controller/api/v1"
 	infrav1beta1 "github.com/DoodleScheduling/webhook-controller/api/v1beta1"
 	"github.com/DoodleScheduling/webhook-controller/internal/controllers"
 	"github.com/DoodleScheduling/webhook-controller/internal/otelsetup"
@@ -40,14 +42,20 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
 	ctrlcache "sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	// +kubebuilder:scaffold:imports
 )
 
 const controllerName = "webhook-controller"
 
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
@@ -55,6 +63,7 @@ var (
 
 func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
+	_ = infrav1.AddToScheme(scheme)
 	_ = infrav1beta1.AddToScheme(scheme)
 	_ = corev1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
@@ -62,10 +71,34 @@ func init() {
 
 var (
 	httpAddr                = ":8080"
+	httpTLSCert             string
+	httpTLSKey              string
+	httpTLSClientCA         string
+	httpEnableHTTP2         bool
+	httpEnableH2C           bool
+	httpReadTimeout         time.Duration
+	httpReadHeaderTimeout   time.Duration
+	httpWriteTimeout        time.Duration
+	httpIdleTimeout         time.Duration
+	adminAddr               string
+	spoolDir                string
+	defaultBodySizeLimit    int64
+	logRequestURI           bool
+	deadLetterBodySizeLimit int64
+	deadLetterRedactHeaders []string
+	failureEventInterval    time.Duration
+	deliveryStatsInterval   time.Duration
+	deliveryStatsWindow     time.Duration
+	degradedFailureRatio    float64
+	allowCrossNamespace     bool
+	enableWebhooks          bool
+	webhookPort             int
+	webhookCertDir          string
 	metricsAddr             string
 	healthAddr              string
 	concurrent              int
 	gracefulShutdownTimeout time.Duration
+	shutdownDelay           time.Duration
 	clientOptions           client.Options
 	kubeConfigOpts          client.KubeConfigOptions
 	logOptions              logger.Options
@@ -77,6 +110,46 @@ var (
 
 func main() {
 	flag.StringVar(&httpAddr, "http-addr", ":8080", "The address of http server binding to.")
+	flag.StringVar(&httpTLSCert, "http-tls-cert", "", "The tls certificate file of the http server, the server uses plain http if not set. Changes are reloaded automatically.")
+	flag.StringVar(&httpTLSKey, "http-tls-key", "", "The tls key file of the http server.")
+	flag.StringVar(&httpTLSClientCA, "http-tls-client-ca", "", "The CA file used to verify client certificates, clients are not required to present a certificate if not set.")
+	flag.BoolVar(&httpEnableHTTP2, "http-enable-http2", true, "Enable HTTP/2 over tls for the http server.")
+	flag.BoolVar(&httpEnableH2C, "http-enable-h2c", false, "Enable HTTP/2 without tls (h2c) for the http server.")
+	flag.DurationVar(&httpReadTimeout, "http-read-timeout", 0,
+		"The maximum duration for reading an entire request including the body, 0 means no timeout.")
+	flag.DurationVar(&httpReadHeaderTimeout, "http-read-header-timeout", 10*time.Second,
+		"The maximum duration for reading the request headers.")
+	flag.DurationVar(&httpWriteTimeout, "http-write-timeout", 0,
+		"The maximum duration before timing out writes of the response, must be longer than the receiver timeouts. 0 means no timeout.")
+	flag.DurationVar(&httpIdleTimeout, "http-idle-timeout", 120*time.Second,
+		"The maximum duration to wait for the next request on keep-alive connections.")
+	flag.StringVar(&adminAddr, "admin-addr", "",
+		"The address the admin api binds to, disabled if empty. Requests are authenticated and authorized using the Kubernetes API.")
+	flag.StringVar(&spoolDir, "spool-dir", os.TempDir(), "The directory used to spill streamed request bodies of slow targets to disk.")
+	flag.Int64Var(&defaultBodySizeLimit, "default-body-size-limit", 0,
+		"The body size limit in bytes for receivers which do not define one, 0 means unlimited.")
+	flag.BoolVar(&logRequestURI, "log-request-uri", false,
+		"Include the request uri of incoming webhooks in logs, it is redacted by default since it contains the secret webhook path.")
+	flag.Int64Var(&deadLetterBodySizeLimit, "dead-letter-body-size-limit", proxy.DefaultDeadLetterBodySizeLimit,
+		"The number of body bytes kept per dead letter in the admin api, up to 100 dead letters are kept.")
+	flag.StringSliceVar(&deadLetterRedactHeaders, "dead-letter-redact-headers", nil,
+		"Headers redacted in dead letters in addition to headers which look sensitive like Authorization, cookies, tokens or signatures.")
+	flag.DurationVar(&failureEventInterval, "failure-event-interval", proxy.DefaultFailureEventInterval,
+		"The interval in which failed deliveries are aggregated into a single warning event per Receiver target.")
+	flag.DurationVar(&deliveryStatsInterval, "delivery-stats-interval", time.Minute,
+		"The interval in which the delivery statistics are written to the Receiver status.")
+	flag.DurationVar(&deliveryStatsWindow, "delivery-stats-window", proxy.DefaultStatsWindow,
+		"The rolling window the delivery counts in the Receiver status are calculated over.")
+	flag.Float64Var(&degradedFailureRatio, "degraded-failure-ratio", 0.5,
+		"A Receiver is Degraded if the ratio of failed deliveries of any target within the delivery stats window exceeds it, 0 disables the Degraded condition.")
+	flag.BoolVar(&allowCrossNamespace, "allow-cross-namespace-targets", false,
+		"Allow Receivers to target services in other namespaces without a WebhookTargetGrant.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Serve the validating admission and conversion webhooks for Receivers and ClusterReceivers.")
+	flag.IntVar(&webhookPort, "webhook-port", 9443,
+		"The port the admission webhook server binds to.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "",
+		"The directory containing tls.crt and tls.key of the admission webhook server, defaults to <temp-dir>/k8s-webhook-server/serving-certs.")
 	flag.StringVar(&metricsAddr, "metrics-addr", ":9556",
 		"The address the metric endpoint binds to.")
 	flag.StringVar(&healthAddr, "health-addr", ":9557",
@@ -84,7 +157,9 @@ func main() {
 	flag.IntVar(&concurrent, "concurrent", 4,
 		"The number of concurrent Pod reconciles.")
 	flag.DurationVar(&gracefulShutdownTimeout, "graceful-shutdown-timeout", 600*time.Second,
-		"The duration given to the reconciler to finish before forcibly stopping.")
+		"The duration given to the reconciler and in-flight webhook deliveries to finish before forcibly stopping.")
+	flag.DurationVar(&shutdownDelay, "shutdown-delay", 5*time.Second,
+		"The duration new webhooks are still accepted on shutdown after the readiness probe failed, until endpoints and load balancers removed the pod.")
 
 	clientOptions.BindFlags(flag.CommandLine)
 	logOptions.BindFlags(flag.CommandLine)
@@ -97,6 +172,9 @@ func main() {
 	flag.Parse()
 	logger.SetLogger(logger.NewLogger(logOptions))
 
+	// The http server accepts webhooks during the shutdown delay before in-flight deliveries are awaited
+	managerShutdownTimeout := shutdownDelay + gracefulShutdownTimeout
+
 	leaderElectionId := fmt.Sprintf("%s-%s", controllerName, "leader-election")
 	if watchOptions.LabelSelector != "" {
 		leaderElectionId = leaderelection.GenerateID(leaderElectionId, watchOptions.LabelSelector)
@@ -119,11 +197,21 @@ func main() {
 		LeaseDuration:                 &leaderElectionOptions.LeaseDuration,
 		RenewDeadline:                 &leaderElectionOptions.RenewDeadline,
 		RetryPeriod:                   &leaderElectionOptions.RetryPeriod,
-		GracefulShutdownTimeout:       &gracefulShutdownTimeout,
+		GracefulShutdownTimeout:       &managerShutdownTimeout,
 		LeaderElectionID:              leaderElectionId,
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Port:    webhookPort,
+			CertDir: webhookCertDir,
+		}),
 		Cache: ctrlcache.Options{
 			ByObject: map[ctrlclient.Object]ctrlcache.ByObject{
-				&infrav1beta1.Receiver{}: {Label: watchSelector},
+				&infrav1.Receiver{}: {Label: watchSelector},
+			},
+		},
+		// The delivery reports are only read by the leader once per interval, caching would watch all ConfigMaps of the cluster
+		Client: ctrlclient.Options{
+			Cache: &ctrlclient.CacheOptions{
+				DisableFor: []ctrlclient.Object{&corev1.ConfigMap{}},
 			},
 		},
 	}
@@ -139,22 +227,16 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Add liveness probe
-	err = mgr.AddHealthzCheck("healthz", healthz.Ping)
-	if err != nil {
-		setupLog.Error(err, "Could not add liveness probe")
-		os.Exit(1)
-	}
-
-	// Add readiness probe
-	err = mgr.AddReadyzCheck("readyz", healthz.Ping)
-	if err != nil {
-		setupLog.Error(err, "Could not add readiness probe")
-		os.Exit(1)
-	}
-
 	proxyOpts := proxy.Options{
-		Logger: setupLog,
+		Logger:                  setupLog,
+		SpoolDir:                spoolDir,
+		Recorder:                mgr.GetEventRecorderFor("webhook-proxy"),
+		DefaultBodySizeLimit:    defaultBodySizeLimit,
+		LogRequestURI:           logRequestURI,
+		DeadLetterBodySizeLimit: deadLetterBodySizeLimit,
+		RedactHeaders:           deadLetterRedactHeaders,
+		FailureEventInterval:    failureEventInterval,
+		StatsWindow:             deliveryStatsWindow,
 		Client: &http.Client{
 			Transport: otelhttp.NewTransport(http.DefaultTransport),
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
@@ -163,34 +245,117 @@ func main() {
 		},
 	}
 
-	proxy := proxy.New(proxyOpts)
-	wrappedHandler := otelhttp.NewHandler(proxy, "webhook-controller")
+	httpProxy := proxy.New(proxyOpts)
+	if err := mgr.Add(httpProxy); err != nil {
+		setupLog.Error(err, "unable to add http proxy to manager")
+		os.Exit(1)
+	}
+
+	wrappedHandler := otelhttp.NewHandler(httpProxy, "webhook-controller")
 
 	httpSrv := &http.Server{
-		Addr:           httpAddr,
-		Handler:        wrappedHandler,
-		MaxHeaderBytes: 1 << 20,
+		Addr:              httpAddr,
+		Handler:           wrappedHandler,
+		MaxHeaderBytes:    1 << 20,
+		ReadTimeout:       httpReadTimeout,
+		ReadHeaderTimeout: httpReadHeaderTimeout,
+		WriteTimeout:      httpWriteTimeout,
+		IdleTimeout:       httpIdleTimeout,
+		Protocols:         new(http.Protocols),
 	}
 
-	var wg sync.WaitGroup
-	wg.Add(1)
+	httpSrv.Protocols.SetHTTP1(true)
+	httpSrv.Protocols.SetHTTP2(httpEnableHTTP2)
+	httpSrv.Protocols.SetUnencryptedHTTP2(httpEnableH2C)
 
-	go func() {
-		defer wg.Done()
-		if err := httpSrv.ListenAndServe(); err != nil {
-			setupLog.Error(err, "HTTP server error")
+	var certWatcher *certwatcher.CertWatcher
+	if httpTLSCert != "" || httpTLSKey != "" {
+		certWatcher, err = certwatcher.New(httpTLSCert, httpTLSKey)
+		if err != nil {
+			setupLog.Error(err, "unable to load http server certificate")
+			os.Exit(1)
 		}
 
-		httpSrv.RegisterOnShutdown(func() {
-			proxy.Close()
-		})
-	}()
+		if err := mgr.Add(certWatcher); err != nil {
+			setupLog.Error(err, "unable to add certificate watcher to manager")
+			os.Exit(1)
+		}
+
+		httpSrv.TLSConfig, err = proxy.NewTLSConfig(certWatcher.GetCertificate, httpTLSClientCA)
+		if err != nil {
+			setupLog.Error(err, "unable to configure tls for the http server")
+			os.Exit(1)
+		}
+	}
+
+	if adminAddr != "" {
+		filter, err := filters.WithAuthenticationAndAuthorization(mgr.GetConfig(), mgr.GetHTTPClient())
+		if err != nil {
+			setupLog.Error(err, "unable to configure admin api authentication")
+			os.Exit(1)
+		}
+
+		adminLog := setupLog.WithName("admin")
+		adminHandler, err := filter(adminLog, httpProxy.AdminHandler())
+		if err != nil {
+			setupLog.Error(err, "unable to configure admin api authentication")
+			os.Exit(1)
+		}
+
+		adminSrv := &http.Server{
+			Addr:              adminAddr,
+			Handler:           adminHandler,
+			ReadHeaderTimeout: httpReadHeaderTimeout,
+		}
+
+		adminRunnable := &manager.Server{Name: "admin", Server: adminSrv}
+
+		// The admin api uses the certificate of the webhook listener but does not require client certificates
+		if certWatcher != nil {
+			tlsConfig, err := proxy.NewTLSConfig(certWatcher.GetCertificate, "")
+			if err != nil {
+				setupLog.Error(err, "unable to configure tls for the admin api")
+				os.Exit(1)
+			}
+
+			adminRunnable.Listener, err = tls.Listen("tcp", adminAddr, tlsConfig)
+			if err != nil {
+				setupLog.Error(err, "unable to listen for the admin api")
+				os.Exit(1)
+			}
+		}
+
+		if err := mgr.Add(adminRunnable); err != nil {
+			setupLog.Error(err, "unable to add admin api to manager")
+			os.Exit(1)
+		}
+	}
+
+	proxyServer := proxy.NewServer(httpSrv, httpProxy, proxy.ServerOptions{
+		Logger:          setupLog.WithName("http"),
+		ShutdownDelay:   shutdownDelay,
+		ShutdownTimeout: gracefulShutdownTimeout,
+	})
+
+	if err := mgr.Add(proxyServer); err != nil {
+		setupLog.Error(err, "unable to add http server to manager")
+		os.Exit(1)
+	}
+
+	// Add liveness probe
+	err = mgr.AddHealthzCheck("http", proxyServer.LivezCheck)
+	if err != nil {
+		setupLog.Error(err, "Could not add liveness probe")
+		os.Exit(1)
+	}
 
 	setReconciler := &controllers.ReceiverReconciler{
 		Log:       ctrl.Log.WithName("controllers").WithName("Receiver"),
 		Recorder:  mgr.GetEventRecorderFor("Receiver"),
 		Client:    mgr.GetClient(),
-		HttpProxy: proxy,
+		HttpProxy: httpProxy,
+
+		AllowCrossNamespaceTargets: allowCrossNamespace,
 	}
 
 	if err = setReconciler.SetupWithManager(mgr, controllers.ReceiverReconcilerOptions{
@@ -200,6 +365,91 @@ func main() {
 		os.Exit(1)
 	}
 
+	clusterReconciler := &controllers.ClusterReceiverReconciler{
+		ReceiverReconciler: controllers.ReceiverReconciler{
+			Log:       ctrl.Log.WithName("controllers").WithName("ClusterReceiver"),
+			Recorder:  mgr.GetEventRecorderFor("ClusterReceiver"),
+			Client:    mgr.GetClient(),
+			HttpProxy: httpProxy,
+		},
+	}
+
+	if err = clusterReconciler.SetupWithManager(mgr, controllers.ReceiverReconcilerOptions{
+		MaxConcurrentReconciles: concurrent,
+	}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterReceiver")
+		os.Exit(1)
+	}
+
+	if enableWebhooks {
+		validator := &controllers.ReceiverValidator{
+			Reader:                     mgr.GetClient(),
+			AllowCrossNamespaceTargets: allowCrossNamespace,
+		}
+
+		if err = validator.SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Receiver")
+			os.Exit(1)
+		}
+
+		if err = mgr.AddReadyzCheck("webhook", mgr.GetWebhookServer().StartedChecker()); err != nil {
+			setupLog.Error(err, "Could not add readiness probe")
+			os.Exit(1)
+		}
+	}
+
+	namespace := runtimeNamespace()
+	replica, err := os.Hostname()
+	if err != nil {
+		setupLog.Error(err, "unable to determine replica name")
+		os.Exit(1)
+	}
+
+	if err := mgr.Add(&controllers.DeliveryReportPublisher{
+		Client:    mgr.GetClient(),
+		Stats:     httpProxy,
+		Log:       ctrl.Log.WithName("controllers").WithName("DeliveryReport"),
+		Interval:  deliveryStatsInterval,
+		Namespace: namespace,
+		Replica:   replica,
+		ID:        leaderElectionId,
+	}); err != nil {
+		setupLog.Error(err, "unable to add delivery report publisher to manager")
+		os.Exit(1)
+	}
+
+	if err := mgr.Add(&controllers.DeliveryStatusUpdater{
+		Client:               mgr.GetClient(),
+		Log:                  ctrl.Log.WithName("controllers").WithName("DeliveryStatus"),
+		Interval:             deliveryStatsInterval,
+		Window:               deliveryStatsWindow,
+		Namespace:            namespace,
+		ID:                   leaderElectionId,
+		DegradedFailureRatio: degradedFailureRatio,
+	}); err != nil {
+		setupLog.Error(err, "unable to add delivery status updater to manager")
+		os.Exit(1)
+	}
+
+	// Add readiness probes
+	err = mgr.AddReadyzCheck("http", proxyServer.ReadyzCheck)
+	if err != nil {
+		setupLog.Error(err, "Could not add readiness probe")
+		os.Exit(1)
+	}
+
+	err = mgr.AddReadyzCheck("receivers", setReconciler.ReadyzCheck)
+	if err != nil {
+		setupLog.Error(err, "Could not add readiness probe")
+		os.Exit(1)
+	}
+
+	err = mgr.AddReadyzCheck("clusterreceivers", clusterReconciler.ReadyzCheck)
+	if err != nil {
+		setupLog.Error(err, "Could not add readiness probe")
+		os.Exit(1)
+	}
+
 	if otelOptions.Endpoint != "" {
 		tp, err := otelsetup.Tracing(context.Background(), otelOptions)
 		defer func() {
@@ -220,3 +470,16 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// runtimeNamespace returns the namespace the controller runs in, default if running outside of a cluster
+func runtimeNamespace() string {
+	if namespace := os.Getenv("RUNTIME_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+
+	if namespace, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace"); err == nil {
+		return strings.TrimSpace(string(namespace))
+	}
+
+	return "default"
+}